@@ -17,6 +17,11 @@ func init() {
 	flag.BoolVar(&flags.Migrate, "migrate", false, "migrate tables")
 	flag.BoolVar(&flags.ShowYamlStruct, "yaml", false, "show yaml struct and exit")
 	flag.BoolVar(&flags.UpdateSchedule, "us", false, "update schedule and exit")
+	flag.BoolVar(&flags.MigrateUploads, "migrate-uploads", false, "move dist/uploads to configured storage and exit")
+	flag.BoolVar(&flags.UploadsGC, "gc", false, "remove orphaned uploads and exit")
+	flag.BoolVar(&flags.UploadsGCDry, "gc-dry-run", false, "report orphaned uploads without removing and exit")
+	flag.BoolVar(&flags.MigrateTimeZone, "migrate-time-zone", false, "move performance times stored as UTC wall clock to cinema time zone and exit, run once")
+	flag.BoolVar(&flags.ImportPages, "import-pages", false, "import about, contacts and menu from app.yaml to pages and exit")
 	flag.BoolVar(&flags.DropTable, "drop", false, "WARNING: drops all tables!!!")
 	flag.Parse()
 }
@@ -36,6 +41,10 @@ func main() {
 
 	// Static SPA serve
 	e.Use(middleware.StaticWithConfig(middleware.StaticConfig{
+		// index is rendered by poravkino with meta of route
+		Skipper: func(c echo.Context) bool {
+			return c.Request().URL.Path == "/" || c.Request().URL.Path == "/index.html"
+		},
 		Root:   "dist",       // This is the path to your SPA build folder, the folder that is created from running "npm build"
 		Index:  "index.html", // This is the default html page for your SPA
 		Browse: false,
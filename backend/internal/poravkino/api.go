@@ -33,18 +33,41 @@ func API(e *echo.Echo) {
 	e.GET("/api/cinema", cinema, middleware.Static("test"))
 	// Movies
 	e.GET("/api/movies", getMovies)
+	e.GET("/api/movies/upcoming", upcomingMovies)
 	e.GET("/api/movies/:id", getMovie, regexID)
+	// Images
+	e.GET("/api/images/:name", getImage)
+	e.GET("/uploads/*", getUpload)
+	// Pages
+	e.GET("/api/pages/:slug", getPage)
+	e.GET("/api/menu", getMenu)
+	// Collections, tags and genres
+	e.GET("/api/collections", getCollections)
+	e.GET("/api/collections/:slug", getCollection)
+	e.GET("/api/tags", getTags)
+	e.GET("/api/genres", getGenres)
+	// Search
+	e.GET("/api/search", search)
+	e.GET("/api/search/suggest", searchSuggest)
+	// Events
+	e.GET("/api/events", getEvents)
+	e.GET("/api/events/:id", getEvent, regexID)
 	// Performances
 	e.GET("/api/performances/:id", getPerformance, regexID)
 	// Notifications
 	e.GET("/api/notifications", notifications)
 	// Schedule
 	e.GET("/api/schedule", schedule)
+	e.GET("/api/schedule/calendar", scheduleCalendar)
+	e.GET("/api/schedule.ics", scheduleICS)
+	e.GET("/api/feeds/:format", getFeed)
 	// Sale
 	e.POST("/api/sales", newSale)
 	// e.GET("/api/sales/fail", failSale)
 	e.GET("/api/sales/check", checkSale)
 	e.GET("/api/sales/code", getSaleByCode)
+	e.GET("/api/sales/code/pkpass", getSalePass)
+	e.GET("/api/sales/code/ics", getSaleICS)
 	// e.GET("/api/sales/code/id", getSaleByCodeID)
 	e.GET("/api/sales/code/lost", getLostSaleByCode) // , capthaTooManyRequests(15)
 	// e.GET("/api/sales/checkSaleByOperator", checkSaleByOperator)
@@ -65,6 +88,7 @@ func API(e *echo.Echo) {
 	// Notifications
 	// me
 	r.GET("/me", me)
+	r.GET("/notifications/all", allNotifications)
 	r.POST("/notifications", createNotification)
 	r.GET("/notifications/:id", getNotification)
 	r.PUT("/notifications/:id", updateNotification)
@@ -76,9 +100,65 @@ func API(e *echo.Echo) {
 	r.GET("/sales/return/:id", returnSale)
 	r.GET("/sales/returnBooking/:id", returnSaleBooking)
 	// Movies - to restrict
+	r.GET("/movies/all", allMovies)
+	r.POST("/movies", createMovie)
 	r.PUT("/movies/:id", updateMovie)
+	r.DELETE("/movies/:id", deleteMovie, regexID)
+	r.POST("/movies/:id/merge", mergeMovies, regexID)
+	r.PUT("/movies/:id/upcoming", setMovieUpcoming, regexID)
+	r.GET("/movies/:id/metadata", movieMetadataCandidates, regexID)
+	r.POST("/movies/:id/metadata", refetchMovieMetadata, regexID)
+	// Trailers
+	r.GET("/movies/:id/trailers", getTrailers, regexID)
+	r.GET("/movies/:id/trailers/candidates", trailerCandidates, regexID)
+	r.POST("/movies/:id/trailers", createTrailer, regexID)
+	r.POST("/movies/:id/trailers/upload", uploadTrailer, regexID)
+	r.PUT("/movies/:id/trailers/order", orderTrailers, regexID)
+	r.DELETE("/trailers/:id", deleteTrailer, regexID)
+	// Performances
+	r.PUT("/performances/:id/attributes", updatePerformanceAttributes, regexID)
+	// Pages
+	r.GET("/pages/all", allPages)
+	r.POST("/pages", createPage)
+	r.PUT("/pages/:id", updatePage, regexID)
+	r.DELETE("/pages/:id", deletePage, regexID)
+	r.GET("/pages/:id/revisions", pageRevisions, regexID)
+	r.POST("/pages/:id/revisions/:version/rollback", rollbackPage, regexID)
+	// Tags and collections
+	r.POST("/tags", createTag)
+	r.PUT("/tags/:id", updateTag, regexID)
+	r.DELETE("/tags/:id", deleteTag, regexID)
+	r.PUT("/movies/:id/tags", setMovieTags, regexID)
+	r.GET("/collections/all", allCollections)
+	r.POST("/collections", createCollection)
+	r.PUT("/collections/:id", updateCollection, regexID)
+	r.DELETE("/collections/:id", deleteCollection, regexID)
+	r.PUT("/collections/:id/movies", setCollectionMovies, regexID)
+	// Events
+	r.GET("/events/all", allEvents)
+	r.POST("/events", createEvent)
+	r.PUT("/events/:id", updateEvent, regexID)
+	r.DELETE("/events/:id", deleteEvent, regexID)
+	r.PUT("/events/:id/performances", setEventPerformances, regexID)
 	// Images - to restrict
 	r.POST("/images", postImage)
+	// Email templates
+	r.GET("/emailTemplates", emailTemplates)
+	r.GET("/emailTemplates/:name", emailTemplateVersions)
+	r.POST("/emailTemplates/:name", createEmailTemplate)
+	r.POST("/emailTemplates/:name/preview", previewEmailTemplate)
 	// Update schedule
 	r.GET("/update", updateScheduleHandler)
+
+	// Versioned public API
+	apiV2(e)
+
+	// SPA index with route meta, sitemap and robots
+	e.GET("/sitemap.xml", sitemap)
+	e.GET("/robots.txt", robots)
+	e.GET("/", spaIndex)
+	e.GET("/movie/:id", spaIndex, regexID)
+	e.GET("/performance/:id", spaIndex, regexID)
+	e.GET("/page/:slug", spaIndex)
+	e.RouteNotFound("/*", spaIndex)
 }
@@ -0,0 +1,347 @@
+package poravkino
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eugenetolok/go-poravkino/pkg/model"
+	"github.com/eugenetolok/go-poravkino/pkg/openapi"
+	"github.com/eugenetolok/go-poravkino/pkg/validate"
+	"github.com/labstack/echo/v4"
+)
+
+// v2Route is a public route of /api/v2, it serves v1 handler and documents it
+type v2Route struct {
+	openapi.Route
+	Handler echo.HandlerFunc
+}
+
+func queryParam(name, schemaType, description string, enum ...string) openapi.Parameter {
+	schema := &openapi.Schema{Type: schemaType, Enum: enum}
+	switch schemaType {
+	case "integer":
+		schema.Format = "int64"
+	case "date":
+		schema = &openapi.Schema{Type: "string", Format: "date"}
+	case "time":
+		schema = &openapi.Schema{Type: "string", Pattern: "^[0-9]{1,2}:[0-9]{2}$"}
+	}
+	return openapi.Parameter{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+func requiredParam(param openapi.Parameter) openapi.Parameter {
+	param.Required = true
+	return param
+}
+
+var (
+	rangeParams = []openapi.Parameter{
+		queryParam("date", "date", "one day, YYYY-MM-DD"),
+		queryParam("from", "date", "first day, YYYY-MM-DD"),
+		queryParam("to", "date", "last day, YYYY-MM-DD"),
+	}
+	performanceParams = []openapi.Parameter{
+		queryParam("hall", "string", "hall name"),
+		queryParam("is3d", "boolean", ""),
+		queryParam("time_from", "time", "HH:MM, night performances are after evening ones"),
+		queryParam("time_to", "time", "HH:MM"),
+		queryParam("price_max", "integer", ""),
+		queryParam("age_max", "integer", ""),
+		queryParam("pushkin", "boolean", ""),
+		queryParam("genre", "string", "genre slug"),
+	}
+)
+
+func v2Routes() []v2Route {
+	scheduleParams := append(append([]openapi.Parameter{}, rangeParams...), performanceParams...)
+	scheduleParams = append(scheduleParams,
+		queryParam("sort", "string", "", "count", "name", "time", "price"),
+		queryParam("group", "string", "performances of movie grouped by", "hall", "time"),
+	)
+	return []v2Route{
+		{openapi.Route{Method: http.MethodGet, Path: "/cinema", Summary: "Cinema settings", Tag: "cinema", Response: model.CinemaSettings{}}, cinema},
+		{openapi.Route{Method: http.MethodGet, Path: "/movies", Summary: "Active movies", Tag: "movies", Params: []openapi.Parameter{
+			queryParam("tag", "string", "tag slug"),
+			queryParam("genre", "string", "genre slug"),
+			queryParam("age_max", "integer", ""),
+		}, Response: []model.Movie{}}, getMovies},
+		{openapi.Route{Method: http.MethodGet, Path: "/movies/upcoming", Summary: "Upcoming movies", Tag: "movies", Response: []model.Movie{}}, upcomingMovies},
+		{openapi.Route{Method: http.MethodGet, Path: "/movies/:id", Summary: "Movie", Tag: "movies", Response: model.Movie{}}, getMovie},
+		{openapi.Route{Method: http.MethodGet, Path: "/schedule", Summary: "Movies with performances of days", Tag: "schedule", Params: scheduleParams, Response: []model.Movie{}}, schedule},
+		{openapi.Route{Method: http.MethodGet, Path: "/schedule/calendar", Summary: "Days with performances", Tag: "schedule", Params: rangeParams, Response: []model.ScheduleDay{}}, scheduleCalendar},
+		{openapi.Route{Method: http.MethodGet, Path: "/performances/:id", Summary: "Performance", Tag: "schedule", Response: model.Performance{}}, getPerformance},
+		{openapi.Route{Method: http.MethodGet, Path: "/search", Summary: "Search movies", Tag: "search", Params: []openapi.Parameter{
+			requiredParam(queryParam("q", "string", "")),
+		}, Response: []model.Movie{}}, search},
+		{openapi.Route{Method: http.MethodGet, Path: "/search/suggest", Summary: "Search suggestions", Tag: "search", Params: []openapi.Parameter{
+			requiredParam(queryParam("q", "string", "")),
+		}, Response: []MovieSuggestion{}}, searchSuggest},
+		{openapi.Route{Method: http.MethodGet, Path: "/collections", Summary: "Collections", Tag: "movies", Response: []model.Collection{}}, getCollections},
+		{openapi.Route{Method: http.MethodGet, Path: "/collections/:slug", Summary: "Collection with movies", Tag: "movies", Response: model.Collection{}}, getCollection},
+		{openapi.Route{Method: http.MethodGet, Path: "/tags", Summary: "Tags", Tag: "movies", Response: []model.Tag{}}, getTags},
+		{openapi.Route{Method: http.MethodGet, Path: "/genres", Summary: "Genres", Tag: "movies", Response: []model.Genre{}}, getGenres},
+		{openapi.Route{Method: http.MethodGet, Path: "/events", Summary: "Events", Tag: "events", Response: []model.Event{}}, getEvents},
+		{openapi.Route{Method: http.MethodGet, Path: "/events/:id", Summary: "Event", Tag: "events", Response: model.Event{}}, getEvent},
+		{openapi.Route{Method: http.MethodGet, Path: "/pages/:slug", Summary: "Page", Tag: "pages", Response: model.Page{}}, getPage},
+		{openapi.Route{Method: http.MethodGet, Path: "/menu", Summary: "Menu", Tag: "pages", Response: []model.MenuItem{}}, getMenu},
+		{openapi.Route{Method: http.MethodGet, Path: "/notifications", Summary: "Active notifications", Tag: "pages", Params: []openapi.Parameter{
+			queryParam("context", "string", "page of notifications: movie, checkout or any other for common ones"),
+			queryParam("movie_id", "integer", ""),
+			queryParam("cinema_id", "integer", ""),
+			queryParam("type", "string", ""),
+		}, Response: []model.Notification{}}, notifications},
+		{openapi.Route{Method: http.MethodPost, Path: "/sales", Summary: "New sale, returns payment form", Tag: "sales", Body: model.PreSale{}, Response: model.PaymentURL{}}, newSaleV2},
+	}
+}
+
+// apiV2 serves public routes under /api/v2 with error envelope, validation and OpenAPI document,
+// v1 routes stay as they are
+func apiV2(e *echo.Echo) {
+	routes := v2Routes()
+	documented := make([]openapi.Route, 0, len(routes)+1)
+	g := e.Group("/api/v2", v2Envelope)
+	for _, route := range routes {
+		g.Add(route.Method, route.Path, route.Handler, v2Validate(route.Route))
+		documented = append(documented, route.Route)
+	}
+	documented = append(documented, openapi.Route{Method: http.MethodGet, Path: "/openapi.json", Summary: "This document", Tag: "meta"})
+	document := openapi.Build(
+		openapi.Info{Title: appSettings.CinemaSettings.CinemaName + " API", Version: "2.0.0"},
+		"/api/v2", documented, model.APIError{},
+	)
+	g.GET("/openapi.json", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, document)
+	})
+	g.RouteNotFound("/*", func(c echo.Context) error {
+		return c.String(http.StatusNotFound, `{"error": "not found"}`)
+	})
+}
+
+// v2Code returns error code of status
+func v2Code(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "bad_request"
+	case http.StatusUnauthorized:
+		return "unauthorized"
+	case http.StatusForbidden:
+		return "forbidden"
+	case http.StatusNotFound:
+		return "not_found"
+	case http.StatusMethodNotAllowed:
+		return "method_not_allowed"
+	case http.StatusConflict:
+		return "conflict"
+	case http.StatusUnprocessableEntity:
+		return "validation_failed"
+	case http.StatusTooManyRequests:
+		return "too_many_requests"
+	}
+	if status >= 500 {
+		return "internal_error"
+	}
+	return "error"
+}
+
+// v2Messages are messages of errors of v1 handlers by code
+var v2Messages = map[string]string{
+	"bad_request":        "Request is malformed",
+	"unauthorized":       "Authentication is required",
+	"forbidden":          "Access is denied",
+	"not_found":          "Resource is not found",
+	"method_not_allowed": "Method is not allowed",
+	"conflict":           "Request conflicts with current state",
+	"validation_failed":  "Validation failed",
+	"too_many_requests":  "Too many requests",
+	"internal_error":     "Internal error",
+}
+
+// v2Message returns stable message of code, status text if code has none
+func v2Message(code string, status int) string {
+	if message, ok := v2Messages[code]; ok {
+		return message
+	}
+	return http.StatusText(status)
+}
+
+// v2Error returns error envelope
+func v2Error(c echo.Context, status int, message string, details map[string]string) error {
+	return v2ErrorCode(c, status, v2Code(status), message, details)
+}
+
+// v2ErrorCode returns error envelope with code more specific than status one
+func v2ErrorCode(c echo.Context, status int, code, message string, details map[string]string) error {
+	return c.JSON(status, model.APIError{Error: model.APIErrorBody{Code: code, Message: message, Details: details}})
+}
+
+// newSaleV2 creates sale like newSale, failures get codes clients can handle
+func newSaleV2(c echo.Context) error {
+	var preSale model.PreSale
+	if err := c.Bind(&preSale); err != nil {
+		return v2Error(c, http.StatusBadRequest, "Body is not valid json", nil)
+	}
+	sale, err := createSale(c, preSale)
+	switch err {
+	case nil:
+		return c.JSON(http.StatusOK, model.PaymentURL{URL: sale.BankPaymentForm})
+	case errSalePlaces:
+		return v2Error(c, http.StatusUnprocessableEntity, fmt.Sprintf("From 1 to %d places are allowed", maxPlacesPerSale),
+			map[string]string{"places": fmt.Sprintf("must be from 1 to %d items", maxPlacesPerSale)})
+	case errSalePerformance:
+		return v2ErrorCode(c, http.StatusNotFound, "performance_not_found", "Performance is not found", nil)
+	case errSalePushkin:
+		return v2ErrorCode(c, http.StatusUnprocessableEntity, "pushkin_not_allowed", "Performance is not sold by Pushkin card",
+			map[string]string{"pushkin": "is not allowed for performance"})
+	case errBookingPlaces:
+		return v2ErrorCode(c, http.StatusConflict, "places_unavailable", "Places are taken or not for sale", nil)
+	}
+	return v2ErrorCode(c, http.StatusBadGateway, "payment_unavailable", "Payment system doesn't accept payment", nil)
+}
+
+// v2Recorder keeps response of v1 handler, so errors can be wrapped into envelope
+type v2Recorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *v2Recorder) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *v2Recorder) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+// v2Envelope turns v1 errors, {"error": "..."} or plain text, into error envelope
+// and sends json of v1 handlers as application/json
+func v2Envelope(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		res := c.Response()
+		writer := res.Writer
+		recorder := &v2Recorder{ResponseWriter: writer, status: http.StatusOK}
+		res.Writer = recorder
+		err := next(c)
+		res.Writer = writer
+
+		status, body := recorder.status, recorder.body.Bytes()
+		if err != nil {
+			status, body = http.StatusInternalServerError, nil
+			if he, ok := err.(*echo.HTTPError); ok {
+				status = he.Code
+				if message, ok := he.Message.(string); ok {
+					body = []byte(message)
+				}
+			} else {
+				log.Println("api v2 error:", err)
+			}
+		}
+		if status < http.StatusBadRequest {
+			if strings.HasPrefix(res.Header().Get(echo.HeaderContentType), echo.MIMETextPlain) && json.Valid(body) {
+				res.Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+			}
+			writer.WriteHeader(status)
+			_, err = writer.Write(body)
+			return err
+		}
+
+		var envelope model.APIError
+		if json.Unmarshal(body, &envelope) != nil || envelope.Error.Code == "" {
+			// v1 messages are not stable and some are russian, so message follows code
+			code := v2Code(status)
+			envelope = model.APIError{Error: model.APIErrorBody{Code: code, Message: v2Message(code, status)}}
+		}
+		data, err := json.Marshal(envelope)
+		if err != nil {
+			return err
+		}
+		res.Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		res.Status = status
+		writer.WriteHeader(status)
+		_, err = writer.Write(data)
+		return err
+	}
+}
+
+// v2Validate checks parameters and json body of route before handler
+func v2Validate(route openapi.Route) echo.MiddlewareFunc {
+	params := append(openapi.PathParams(route.Path), route.Params...)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			details := map[string]string{}
+			for _, param := range params {
+				value := c.QueryParam(param.Name)
+				if param.In == "path" {
+					value = c.Param(param.Name)
+				}
+				if message := checkParam(param, value); message != "" {
+					details[param.Name] = message
+				}
+			}
+			if route.Body != nil && len(details) == 0 {
+				data, err := io.ReadAll(c.Request().Body)
+				if err != nil {
+					return v2Error(c, http.StatusBadRequest, "can't read body", nil)
+				}
+				c.Request().Body = io.NopCloser(bytes.NewReader(data))
+				body := reflect.New(reflect.TypeOf(route.Body)).Interface()
+				if err := json.Unmarshal(data, body); err != nil {
+					return v2Error(c, http.StatusBadRequest, "body is not valid json", nil)
+				}
+				for name, message := range validate.Struct(body) {
+					details[name] = message
+				}
+			}
+			if len(details) > 0 {
+				return v2Error(c, http.StatusUnprocessableEntity, "validation failed", details)
+			}
+			return next(c)
+		}
+	}
+}
+
+// checkParam returns error of parameter value, empty if it is valid
+func checkParam(param openapi.Parameter, value string) string {
+	if value == "" {
+		if param.Required {
+			return "is required"
+		}
+		return ""
+	}
+	schema := param.Schema
+	switch {
+	case schema.Type == "integer":
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return "must be an integer"
+		}
+	case schema.Type == "boolean":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return "must be true or false"
+		}
+	case schema.Format == "date":
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			return "must be a date YYYY-MM-DD"
+		}
+	case schema.Pattern != "":
+		if _, ok := dayMinutes(value); !ok {
+			return "must be a time HH:MM"
+		}
+	}
+	if len(schema.Enum) > 0 {
+		for _, allowed := range schema.Enum {
+			if value == allowed {
+				return ""
+			}
+		}
+		return "must be one of: " + strings.Join(schema.Enum, ", ")
+	}
+	return ""
+}
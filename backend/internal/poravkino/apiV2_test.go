@@ -0,0 +1,90 @@
+package poravkino
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/eugenetolok/go-poravkino/pkg/model"
+	"github.com/labstack/echo/v4"
+)
+
+func TestV2Envelope(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler echo.HandlerFunc
+		status  int
+		code    string
+		message string
+	}{
+		{"v1 russian error", func(c echo.Context) error {
+			return c.String(http.StatusNotFound, `{"error": "сеанс не найден"}`)
+		}, http.StatusNotFound, "not_found", "Resource is not found"},
+		{"v1 plain text", func(c echo.Context) error {
+			return c.String(http.StatusBadRequest, "bad request")
+		}, http.StatusBadRequest, "bad_request", "Request is malformed"},
+		{"echo error", func(c echo.Context) error {
+			return echo.NewHTTPError(http.StatusTooManyRequests, "слишком часто")
+		}, http.StatusTooManyRequests, "too_many_requests", "Too many requests"},
+		{"handler error", func(c echo.Context) error {
+			return errors.New("database is down")
+		}, http.StatusInternalServerError, "internal_error", "Internal error"},
+		{"v2 error", func(c echo.Context) error {
+			return v2Error(c, http.StatusUnprocessableEntity, "Seats are taken", map[string]string{"seats": "taken"})
+		}, http.StatusUnprocessableEntity, "validation_failed", "Seats are taken"},
+		{"unknown status", func(c echo.Context) error {
+			return c.String(http.StatusGone, `{"error": "удалено"}`)
+		}, http.StatusGone, "error", "Gone"},
+	}
+	for _, tt := range tests {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+		if err := v2Envelope(tt.handler)(e.NewContext(req, rec)); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var envelope model.APIError
+		if err := json.Unmarshal(rec.Body.Bytes(), &envelope); err != nil {
+			t.Fatalf("%s: %s", tt.name, rec.Body)
+		}
+		if rec.Code != tt.status || envelope.Error.Code != tt.code || envelope.Error.Message != tt.message {
+			t.Errorf("%s: %d %+v", tt.name, rec.Code, envelope.Error)
+		}
+	}
+}
+
+func TestV2EnvelopeSuccess(t *testing.T) {
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	handler := func(c echo.Context) error {
+		return c.String(http.StatusOK, `{"id": 1}`)
+	}
+	if err := v2Envelope(handler)(e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK || rec.Body.String() != `{"id": 1}` || rec.Header().Get(echo.HeaderContentType) != echo.MIMEApplicationJSONCharsetUTF8 {
+		t.Fatalf("%d %s %s", rec.Code, rec.Header().Get(echo.HeaderContentType), rec.Body)
+	}
+}
+
+func TestNewSaleV2Places(t *testing.T) {
+	for _, body := range []string{`{"performance_id": 1, "places": []}`, `{"performance_id": 1, "places": [1, 2, 3, 4, 5, 6]}`} {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		if err := v2Envelope(newSaleV2)(e.NewContext(req, rec)); err != nil {
+			t.Fatal(err)
+		}
+		var envelope model.APIError
+		if err := json.Unmarshal(rec.Body.Bytes(), &envelope); err != nil {
+			t.Fatalf("%s", rec.Body)
+		}
+		if rec.Code != http.StatusUnprocessableEntity || envelope.Error.Code != "validation_failed" || envelope.Error.Details["places"] == "" {
+			t.Errorf("%s: %d %+v", body, rec.Code, envelope.Error)
+		}
+	}
+}
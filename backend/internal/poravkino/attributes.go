@@ -0,0 +1,72 @@
+package poravkino
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/eugenetolok/go-poravkino/pkg/attributes"
+	"github.com/eugenetolok/go-poravkino/pkg/model"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// performanceAttributes derives attributes of synced performance by rules from settings
+func performanceAttributes(performance model.Performance, film model.Movie) model.PerformanceAttributes {
+	a, errs := attributes.Derive(appSettings.PerformanceSettings, attributes.Input{
+		Movie:   film.Name,
+		Hall:    performance.HallName,
+		Variant: performance.Variant,
+		ThreeD:  performance.ThreeD,
+		Time:    performance.Time,
+		Age:     film.Age,
+	})
+	for _, err := range errs {
+		log.Println("performance attribute rule error:", err)
+	}
+	return a
+}
+
+// updatePerformanceAttributes sets attributes by editor, locked ones are not changed by sync
+func updatePerformanceAttributes(c echo.Context) error {
+	var performance model.Performance
+	if err := db.First(&performance, c.Param("id")).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.String(http.StatusNotFound, `{"error": "no such performance"}`)
+	}
+	var attributesIn model.PerformanceAttributesIn
+	if err := c.Bind(&attributesIn); err != nil {
+		return c.String(http.StatusBadRequest, `{"error": "bad request"}`)
+	}
+	performance.PerformanceAttributes = attributesIn.PerformanceAttributes
+	performance.AttributesLocked = attributesIn.Locked
+	if !performance.AttributesLocked {
+		var film model.Movie
+		db.First(&film, performance.MovieID)
+		performance.PerformanceAttributes = performanceAttributes(performance, film)
+	}
+	db.Save(&performance)
+	return c.JSON(http.StatusOK, performance)
+}
+
+// performanceFilters filters performances by attributes from query, e.g. ?format=3D&subtitles=true
+func performanceFilters(c echo.Context) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if format := c.QueryParam("format"); format != "" {
+			db = db.Where("performances.format = ?", format)
+		}
+		for _, name := range []string{
+			attributes.OriginalLanguage,
+			attributes.Subtitles,
+			attributes.AudioDescription,
+			attributes.Relaxed,
+			attributes.Kids,
+			attributes.LateShow,
+		} {
+			if value, err := strconv.ParseBool(c.QueryParam(name)); err == nil {
+				db = db.Where("performances."+name+" = ?", value)
+			}
+		}
+		return db
+	}
+}
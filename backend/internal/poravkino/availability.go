@@ -0,0 +1,69 @@
+package poravkino
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/eugenetolok/go-poravkino/pkg/extapi"
+	"github.com/eugenetolok/go-poravkino/pkg/model"
+)
+
+// nearlySoldOut checks share of free seats against settings, unknown totals are not sold out
+func nearlySoldOut(free, total int64) bool {
+	percent := appSettings.PerformanceSettings.NearlySoldOutPercent
+	if percent == 0 {
+		percent = 10
+	}
+	return total > 0 && free*100 <= total*percent
+}
+
+// availabilityWorkers limits parallel requests to booking system
+const availabilityWorkers = 4
+
+// availabilityLock skips cron run while previous one is still running
+var availabilityLock sync.Mutex
+
+// updateAvailability stores free and total seats of near performances
+func updateAvailability() {
+	if !availabilityLock.TryLock() {
+		log.Println("availability update is still running")
+		return
+	}
+	defer availabilityLock.Unlock()
+	days := appSettings.PerformanceSettings.AvailabilityDays
+	if days == 0 {
+		days = 7
+	}
+	var performances []model.Performance
+	db.Where("is_active = ? AND time > ? AND time < ?", true, time.Now(), time.Now().AddDate(0, 0, int(days))).Find(&performances)
+	var updated int64
+	var wg sync.WaitGroup
+	queue := make(chan model.Performance)
+	for i := 0; i < availabilityWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for performance := range queue {
+				external, err := extapi.GetPerformance(performance.ExternalID, 1)
+				if err != nil || external.Data.ID == 0 {
+					continue
+				}
+				total := int64(len(halls[external.Data.HallID]))
+				free := int64(len(external.Data.Places))
+				if total < free {
+					total = free
+				}
+				db.Model(&performance).Updates(map[string]interface{}{"seats_free": free, "seats_total": total})
+				atomic.AddInt64(&updated, 1)
+			}
+		}()
+	}
+	for _, performance := range performances {
+		queue <- performance
+	}
+	close(queue)
+	wg.Wait()
+	log.Printf("availability of %d performances is updated", updated)
+}
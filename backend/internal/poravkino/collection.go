@@ -0,0 +1,131 @@
+package poravkino
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/eugenetolok/go-poravkino/pkg/model"
+	"github.com/eugenetolok/go-poravkino/pkg/utils"
+	"github.com/jinzhu/copier"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// activeMovies preloads only movies shown on site
+func activeMovies(db *gorm.DB) *gorm.DB {
+	return db.Where("movies.is_active = ? AND movies.merged_into_id = 0 AND movies.parent_id = 0", true).Order("movies.index DESC")
+}
+
+// collectionMovies fills movies of collection backed by tag
+func collectionMovies(collection *model.Collection) {
+	if collection.TagID == 0 {
+		return
+	}
+	collection.Movies = []model.Movie{}
+	activeMovies(db).
+		Joins("JOIN movie_tags ON movie_tags.movie_id = movies.id").
+		Where("movie_tags.tag_id = ?", collection.TagID).
+		Find(&collection.Movies)
+}
+
+// getCollections returns active collections with their movies
+func getCollections(c echo.Context) error {
+	collections := []model.Collection{}
+	db.Preload("Movies", activeMovies).Where("is_active = ?", true).Order(`"order" ASC, id ASC`).Find(&collections)
+	for i := range collections {
+		collectionMovies(&collections[i])
+	}
+	return c.JSON(http.StatusOK, collections)
+}
+
+// getCollection returns active collection by slug
+func getCollection(c echo.Context) error {
+	var collection model.Collection
+	if err := db.Preload("Movies", activeMovies).Where("slug = ? AND is_active = ?", c.Param("slug"), true).First(&collection).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.String(http.StatusNotFound, `{"error": "no such collection"}`)
+	}
+	collectionMovies(&collection)
+	return c.JSON(http.StatusOK, collection)
+}
+
+// allCollections returns collections for editors
+func allCollections(c echo.Context) error {
+	collections := []model.Collection{}
+	db.Preload("Movies").Order(`"order" ASC, id ASC`).Find(&collections)
+	return c.JSON(http.StatusOK, collections)
+}
+
+// bindCollection binds collection, slug is made from title if empty
+func bindCollection(c echo.Context, collection *model.Collection) bool {
+	var collectionIn model.CollectionIn
+	if err := c.Bind(&collectionIn); err != nil || collectionIn.Title == "" {
+		return false
+	}
+	copier.Copy(collection, &collectionIn)
+	if collection.Slug == "" {
+		collection.Slug = utils.Slug(collection.Title)
+	}
+	return true
+}
+
+// createCollection creates collection
+func createCollection(c echo.Context) error {
+	var collection model.Collection
+	if !bindCollection(c, &collection) {
+		return c.String(http.StatusBadRequest, `{"error": "bad request"}`)
+	}
+	if err := db.Create(&collection).Error; err != nil {
+		return c.String(http.StatusConflict, `{"error": "collection already exists"}`)
+	}
+	return c.JSON(http.StatusCreated, collection)
+}
+
+// updateCollection updates collection
+func updateCollection(c echo.Context) error {
+	var collection model.Collection
+	if err := db.First(&collection, c.Param("id")).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.String(http.StatusNotFound, `{"error": "no such collection"}`)
+	}
+	if !bindCollection(c, &collection) {
+		return c.String(http.StatusBadRequest, `{"error": "bad request"}`)
+	}
+	if err := db.Save(&collection).Error; err != nil {
+		return c.String(http.StatusConflict, `{"error": "collection already exists"}`)
+	}
+	return c.JSON(http.StatusOK, collection)
+}
+
+// deleteCollection deletes collection
+func deleteCollection(c echo.Context) error {
+	var collection model.Collection
+	if err := db.First(&collection, c.Param("id")).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.String(http.StatusNotFound, `{"error": "no such collection"}`)
+	}
+	db.Model(&collection).Association("Movies").Clear()
+	db.Delete(&collection)
+	return c.String(http.StatusOK, `{"success":"collection has been deleted"}`)
+}
+
+// setCollectionMovies replaces chosen movies of collection
+func setCollectionMovies(c echo.Context) error {
+	var collection model.Collection
+	if err := db.First(&collection, c.Param("id")).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.String(http.StatusNotFound, `{"error": "no such collection"}`)
+	}
+	var ids model.IDs
+	if err := c.Bind(&ids); err != nil {
+		return c.String(http.StatusBadRequest, `{"error": "bad request"}`)
+	}
+	movies := []model.Movie{}
+	if len(ids.IDs) > 0 {
+		db.Where("id IN (?)", ids.IDs).Find(&movies)
+	}
+	if len(movies) != len(ids.IDs) {
+		return c.String(http.StatusBadRequest, `{"error": "wrong movie ids"}`)
+	}
+	if err := db.Model(&collection).Association("Movies").Replace(movies); err != nil {
+		return c.String(http.StatusInternalServerError, `{"error": "movies link error"}`)
+	}
+	collection.Movies = movies
+	return c.JSON(http.StatusOK, collection)
+}
@@ -1,12 +1,12 @@
 package poravkino
 
 import (
-	"bytes"
 	"fmt"
-	"html/template"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/eugenetolok/go-poravkino/pkg/extapi"
 	"github.com/eugenetolok/go-poravkino/pkg/model"
@@ -37,6 +37,9 @@ func InitPoravkino(f model.Flags) {
 	}
 	// init configs
 	updateConfig()
+	// db and json times are shown in cinema time zone, zone changes need restart,
+	// cinemaLocation keeps the first zone until then
+	time.Local = cinemaLocation()
 	// init html
 	initHTML()
 	extapi.InitConfig(appSettings.BookingSettings)
@@ -63,20 +66,71 @@ func InitPoravkino(f model.Flags) {
 			model.Performance{},
 			model.Sale{},
 			model.Notification{},
-			model.User{})
+			model.User{},
+			model.SMSMessage{},
+			model.EmailTemplate{},
+			model.Trailer{},
+			model.OrphanedUpload{},
+			model.Event{},
+			"event_performances",
+			model.Tag{},
+			model.Genre{},
+			model.Collection{},
+			"movie_tags",
+			"movie_genres",
+			"collection_movies",
+			model.Page{},
+			model.PageRevision{},
+			model.Migration{})
 		log.Println("All tables are dropped")
 		os.Exit(0)
 	}
 	if f.Migrate {
+		migrateMovies()
+		fillNullColumns(&model.Notification{}, notificationDefaults)
 		db.AutoMigrate(
 			model.Movie{},
 			model.Performance{},
 			model.Sale{},
 			model.Notification{},
-			model.User{})
+			model.User{},
+			model.SMSMessage{},
+			model.EmailTemplate{},
+			model.Trailer{},
+			model.OrphanedUpload{},
+			model.Event{},
+			model.Tag{},
+			model.Genre{},
+			model.Collection{},
+			model.Page{},
+			model.PageRevision{},
+			model.Migration{})
+		// columns added without database default are filled after they are created
+		migrateMovies()
+		migrateTrailers()
+		groupMovieVariants()
+		migrateSearch()
+		migrateGenres()
+		migrateNotifications()
 		log.Println("All tables are migrated")
 		os.Exit(0)
 	}
+	if f.MigrateTimeZone {
+		migrateTimeZone()
+		os.Exit(0)
+	}
+	if f.MigrateUploads {
+		migrateUploads()
+		os.Exit(0)
+	}
+	if f.ImportPages {
+		importPages()
+		os.Exit(0)
+	}
+	if f.UploadsGC || f.UploadsGCDry {
+		collectUploads(f.UploadsGCDry)
+		os.Exit(0)
+	}
 	if f.AddUser {
 		var user model.User
 		user.Username, user.Password, user.Role = promptUser()
@@ -97,10 +151,38 @@ func InitPoravkino(f model.Flags) {
 	c.AddFunc("@every 600s", updateSchedule)
 	c.AddFunc("@every 60s", updateSales)
 	c.AddFunc("@every 60s", sendEmails)
+	c.AddFunc("@every 60s", sendRefundEmails)
+	c.AddFunc("@every 300s", sendReminders)
+	c.AddFunc("@every 300s", sendFollowUps)
+	c.AddFunc("@every 60s", sendTicketsSMS)
+	c.AddFunc("@every 300s", updateSMSStatuses)
+	c.AddFunc("0 0 23 * * *", dailyReport)
 	c.AddFunc("@every 300s", clearIPMap)
+	c.AddFunc("0 30 4 * * *", uploadsGCJob)
+	c.AddFunc("@every 300s", updateAvailability)
 	// c.AddFunc("@every 20s", fixProblemSales)
 	c.AddFunc("@every 10s", updateConfig)
 	c.Start()
+	go runTelegramBot()
+}
+
+// fillNullColumns sets NULL columns of existing rows to defaults,
+// so NOT NULL constraints can be added by AutoMigrate
+func fillNullColumns(table interface{}, defaults map[string]interface{}) {
+	if !db.Migrator().HasTable(table) {
+		return
+	}
+	for column, value := range defaults {
+		if !db.Migrator().HasColumn(table, column) {
+			continue
+		}
+		result := db.Model(table).Where(column+" IS NULL").Update(column, value)
+		if result.Error != nil {
+			log.Println("migration error:", result.Error)
+			continue
+		}
+		log.Printf("%s of %d rows is set to default", column, result.RowsAffected)
+	}
 }
 
 func updateConfig() {
@@ -110,22 +192,6 @@ func updateConfig() {
 	extapi.InitConfig(appSettings.BookingSettings)
 }
 
-func initHTML() {
-	t, err := template.ParseFiles(utils.WorkDir() + "/dist/index.html")
-	if err != nil {
-		log.Print("index template parsing error: ", err)
-	}
-	buf := new(bytes.Buffer)
-	err = t.Execute(buf, appSettings.CinemaSettings)
-	if err != nil { // if there is an error
-		log.Print("index template executing error: ", err)
-	}
-	err = os.WriteFile(utils.WorkDir()+"/dist/index.html", buf.Bytes(), 0644)
-	if err != nil {
-		log.Print("index template write error: ", err)
-	}
-}
-
 // Function to prompt user for username and password using promptui
 func promptUser() (string, string, string) {
 	// Create prompts for username and password
@@ -166,3 +232,11 @@ func promptUser() (string, string, string) {
 
 	return strings.TrimSpace(username), strings.TrimSpace(password), strings.TrimSpace(role)
 }
+
+// workPath resolves relative paths from app.yaml against work dir
+func workPath(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(utils.WorkDir(), path)
+}
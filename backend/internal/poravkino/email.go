@@ -1,9 +1,12 @@
 package poravkino
 
 import (
+	"fmt"
 	"log"
+	"strings"
 	"time"
 
+	"github.com/eugenetolok/go-poravkino/pkg/ical"
 	"github.com/eugenetolok/go-poravkino/pkg/model"
 	"github.com/eugenetolok/go-poravkino/pkg/smtp"
 )
@@ -12,9 +15,110 @@ func sendEmails() {
 	var sales []model.Sale
 	db.Preload("Performance.Movie").Where("bank_order_status = ? AND email_sent = ? AND created_at > ?", 2, false, time.Now().Add(-15*time.Minute)).Find(&sales)
 	for _, sale := range sales {
-		smtp.SendTickets(sale, appSettings.CinemaSettings.DomainName)
+		sendSaleEmail(smtp.TemplateTickets, sale, smtp.Attachment{
+			Name:        "event.ics",
+			ContentType: ical.ContentType,
+			Data:        saleCalendar(sale),
+		})
 		sale.EmailSent = true
 		db.Save(&sale)
 		log.Printf("Email sent for sale %d with secret %s sent to email %s", sale.ExternalID, sale.Secret, sale.Email)
 	}
 }
+
+// sendReminders sends reminders for performances starting within configured hours
+func sendReminders() {
+	settings := appSettings.ReminderSettings
+	if !settings.ReminderEnabled || settings.ReminderHours <= 0 {
+		return
+	}
+	now := cinemaNow()
+	var sales []model.Sale
+	db.Preload("Performance.Movie").
+		Joins("JOIN performances ON performances.id = sales.performance_id").
+		Where("sales.bank_order_status = ? AND sales.refund = ? AND sales.reminder_sent = ?", 2, false, false).
+		Where("performances.time BETWEEN ? AND ?", now, now.Add(time.Hour*time.Duration(settings.ReminderHours))).
+		Find(&sales)
+	for _, sale := range sales {
+		if !sendSaleEmail(smtp.TemplateReminder, sale) {
+			continue
+		}
+		db.Model(&sale).Update("reminder_sent", true)
+		log.Printf("Reminder sent for sale %d with secret %s sent to email %s", sale.ExternalID, sale.Secret, sale.Email)
+	}
+}
+
+// followUpWindow limits follow-ups to recent performances, so enabling them doesn't mail old sales
+const followUpWindow = 72 * time.Hour
+
+// sendFollowUps sends rating requests after performance end
+func sendFollowUps() {
+	settings := appSettings.ReminderSettings
+	if !settings.FollowUpEnabled {
+		return
+	}
+	now := cinemaNow()
+	var sales []model.Sale
+	db.Preload("Performance.Movie").
+		Joins("JOIN performances ON performances.id = sales.performance_id").
+		Joins("JOIN movies ON movies.id = performances.movie_id").
+		Where("sales.bank_order_status = ? AND sales.refund = ? AND sales.follow_up_sent = ?", 2, false, false).
+		Where("performances.time > ?", now.Add(-followUpWindow)).
+		Where("performances.time + (movies.duration + movies.add_duration + ?) * INTERVAL '1 minute' < ?", settings.FollowUpHours*60, now).
+		Find(&sales)
+	for _, sale := range sales {
+		if !sendSaleEmail(smtp.TemplateFollowUp, sale) {
+			continue
+		}
+		db.Model(&sale).Update("follow_up_sent", true)
+		log.Printf("Follow-up sent for sale %d with secret %s sent to email %s", sale.ExternalID, sale.Secret, sale.Email)
+	}
+}
+
+func ratingURL(sale model.Sale) string {
+	rating := appSettings.ReminderSettings.RatingURL
+	if rating == "" {
+		return ""
+	}
+	separator := "?"
+	if strings.Contains(rating, "?") {
+		separator = "&"
+	}
+	return rating + separator + "secret=" + sale.Secret
+}
+
+// sendRefundEmails sends refund confirmations queued by refundSale
+func sendRefundEmails() {
+	var sales []model.Sale
+	db.Preload("Performance.Movie").
+		Where("refund = ? AND refund_email_sent = ? AND email <> '' AND updated_at > ?", true, false, time.Now().Add(-24*time.Hour)).
+		Find(&sales)
+	for _, sale := range sales {
+		if !sendSaleEmail(smtp.TemplateRefund, sale) {
+			continue
+		}
+		db.Model(&sale).Update("refund_email_sent", true)
+		log.Printf("Refund email for sale %d with secret %s sent to email %s", sale.ExternalID, sale.Secret, sale.Email)
+	}
+}
+
+// sendSaleEmail renders current version of template for sale and sends it
+func sendSaleEmail(name string, sale model.Sale, attachments ...smtp.Attachment) bool {
+	emailTemplate := latestEmailTemplate(name, appSettings.BookingSettings.CinemaID)
+	subject, body, err := smtp.Render(emailTemplate.Subject, emailTemplate.Body, saleTemplateData(sale))
+	if err != nil {
+		log.Printf("email template %s error: %s", name, err)
+		return false
+	}
+	return smtp.Send(sale.Email, subject, body, attachments...)
+}
+
+// saleTemplateData adds optional links to template data
+func saleTemplateData(sale model.Sale) smtp.TemplateData {
+	data := smtp.NewTemplateData(sale, appSettings.CinemaSettings)
+	if walletEnabled() {
+		data.Links.Wallet = fmt.Sprintf("https://%s/api/sales/code/pkpass?secret=%s", appSettings.CinemaSettings.DomainName, sale.Secret)
+	}
+	data.Links.Rating = ratingURL(sale)
+	return data
+}
@@ -0,0 +1,128 @@
+package poravkino
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/eugenetolok/go-poravkino/pkg/model"
+	"github.com/eugenetolok/go-poravkino/pkg/smtp"
+	"github.com/eugenetolok/go-poravkino/pkg/utils"
+	"github.com/jackc/pgconn"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// pgUniqueViolation is postgres error code of unique constraint violation
+const pgUniqueViolation = "23505"
+
+// latestEmailTemplate returns the latest version of cinema override, default template or built-in one,
+// cinema 0 means default template
+func latestEmailTemplate(name string, cinemaID int64) model.EmailTemplate {
+	var emailTemplate model.EmailTemplate
+	err := db.Where("name = ? AND cinema_id IN (?)", name, []int64{0, cinemaID}).
+		Order("cinema_id DESC, version DESC").
+		First(&emailTemplate).Error
+	if err != nil {
+		emailTemplate = model.EmailTemplate{Name: name}
+		emailTemplate.Subject, emailTemplate.Body, _ = smtp.DefaultTemplate(name)
+	}
+	return emailTemplate
+}
+
+// emailTemplates returns current templates
+func emailTemplates(c echo.Context) error {
+	if _, role := utils.GetUser(c); role != "admin" {
+		return c.String(http.StatusForbidden, `{"error": "У вас нет прав для редактирования шаблонов"}`)
+	}
+	var templates []model.EmailTemplate
+	for _, name := range smtp.Names() {
+		templates = append(templates, latestEmailTemplate(name, appSettings.BookingSettings.CinemaID))
+	}
+	return c.JSON(http.StatusOK, templates)
+}
+
+// emailTemplateVersions returns all versions of template
+func emailTemplateVersions(c echo.Context) error {
+	if _, role := utils.GetUser(c); role != "admin" {
+		return c.String(http.StatusForbidden, `{"error": "У вас нет прав для редактирования шаблонов"}`)
+	}
+	var templates []model.EmailTemplate
+	db.Where("name = ?", c.Param("name")).Order("cinema_id ASC, version DESC").Find(&templates)
+	return c.JSON(http.StatusOK, templates)
+}
+
+// createEmailTemplate saves new version of template if it renders
+func createEmailTemplate(c echo.Context) error {
+	userID, role := utils.GetUser(c)
+	if role != "admin" {
+		return c.String(http.StatusForbidden, `{"error": "У вас нет прав для редактирования шаблонов"}`)
+	}
+	name := c.Param("name")
+	if _, _, err := smtp.DefaultTemplate(name); err != nil {
+		return c.String(http.StatusNotFound, `{"error": "no such template"}`)
+	}
+	var templateIn model.EmailTemplateIn
+	if err := c.Bind(&templateIn); err != nil {
+		return c.String(http.StatusBadRequest, `{"error": "bad request"}`)
+	}
+	if _, _, err := smtp.Render(templateIn.Subject, templateIn.Body, smtp.SampleData(appSettings.CinemaSettings)); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	emailTemplate := model.EmailTemplate{
+		Name:     name,
+		CinemaID: templateIn.CinemaID,
+		Subject:  templateIn.Subject,
+		Body:     templateIn.Body,
+		AuthorID: userID,
+	}
+	// concurrent saves get the same version, unique index rejects all but one and others take the next
+	for attempt := 0; ; attempt++ {
+		var last model.EmailTemplate
+		db.Where("name = ? AND cinema_id = ?", name, templateIn.CinemaID).Order("version DESC").First(&last)
+		emailTemplate.ID = 0
+		emailTemplate.Version = last.Version + 1
+		err := db.Create(&emailTemplate).Error
+		if err == nil {
+			return c.JSON(http.StatusCreated, emailTemplate)
+		}
+		var pgErr *pgconn.PgError
+		if !errors.As(err, &pgErr) || pgErr.Code != pgUniqueViolation || attempt == 2 {
+			log.Println("email template save error:", err)
+			return c.String(http.StatusInternalServerError, `{"error": "internal server error"}`)
+		}
+	}
+}
+
+// previewEmailTemplate renders template against real or synthetic sale
+func previewEmailTemplate(c echo.Context) error {
+	if _, role := utils.GetUser(c); role != "admin" {
+		return c.String(http.StatusForbidden, `{"error": "У вас нет прав для редактирования шаблонов"}`)
+	}
+	name := c.Param("name")
+	var preview model.EmailTemplatePreview
+	if err := c.Bind(&preview); err != nil {
+		return c.String(http.StatusBadRequest, `{"error": "bad request"}`)
+	}
+	current := latestEmailTemplate(name, preview.CinemaID)
+	subject, body := current.Subject, current.Body
+	if preview.Subject != "" {
+		subject = preview.Subject
+	}
+	if preview.Body != "" {
+		body = preview.Body
+	}
+	data := smtp.SampleData(appSettings.CinemaSettings)
+	if preview.Secret != "" {
+		var sale model.Sale
+		if err := db.Preload("Performance.Movie").Where("secret = ?", preview.Secret).First(&sale).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.String(http.StatusNotFound, `{"error": "no such sale"}`)
+		}
+		data = saleTemplateData(sale)
+	}
+	subject, body, err := smtp.Render(subject, body, data)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, echo.Map{"subject": subject, "body": body})
+}
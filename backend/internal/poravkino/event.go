@@ -0,0 +1,108 @@
+package poravkino
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/eugenetolok/go-poravkino/pkg/model"
+	"github.com/jinzhu/copier"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// activeEventPerformances preloads only future performances of event
+func activeEventPerformances(db *gorm.DB) *gorm.DB {
+	return db.Where("performances.is_active = ?", true).Order("performances.time ASC")
+}
+
+// getEvents returns active events with their performances
+func getEvents(c echo.Context) error {
+	events := []model.Event{}
+	db.Preload("Performances", activeEventPerformances).
+		Preload("Performances.Movie").
+		Where("is_active = ?", true).
+		Order("starts_at ASC").
+		Find(&events)
+	return c.JSON(http.StatusOK, events)
+}
+
+// getEvent returns event by id
+func getEvent(c echo.Context) error {
+	var event model.Event
+	if err := db.Preload("Performances", activeEventPerformances).
+		Preload("Performances.Movie").
+		Where("is_active = ?", true).
+		First(&event, c.Param("id")).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.String(http.StatusNotFound, `{"error": "no such event"}`)
+	}
+	return c.JSON(http.StatusOK, event)
+}
+
+// allEvents returns events for editors including inactive ones
+func allEvents(c echo.Context) error {
+	events := []model.Event{}
+	db.Preload("Performances").Order("starts_at DESC").Find(&events)
+	return c.JSON(http.StatusOK, events)
+}
+
+// createEvent creates event
+func createEvent(c echo.Context) error {
+	var eventIn model.EventIn
+	if err := c.Bind(&eventIn); err != nil || eventIn.Title == "" {
+		return c.String(http.StatusBadRequest, `{"error": "bad request"}`)
+	}
+	var event model.Event
+	copier.Copy(&event, &eventIn)
+	db.Create(&event)
+	return c.JSON(http.StatusCreated, event)
+}
+
+// updateEvent updates event
+func updateEvent(c echo.Context) error {
+	var event model.Event
+	if err := db.First(&event, c.Param("id")).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.String(http.StatusNotFound, `{"error": "no such event"}`)
+	}
+	var eventIn model.EventIn
+	if err := c.Bind(&eventIn); err != nil || eventIn.Title == "" {
+		return c.String(http.StatusBadRequest, `{"error": "bad request"}`)
+	}
+	copier.Copy(&event, &eventIn)
+	db.Save(&event)
+	return c.JSON(http.StatusOK, event)
+}
+
+// deleteEvent deletes event, linked performances stay
+func deleteEvent(c echo.Context) error {
+	var event model.Event
+	if err := db.First(&event, c.Param("id")).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.String(http.StatusNotFound, `{"error": "no such event"}`)
+	}
+	db.Model(&event).Association("Performances").Clear()
+	db.Delete(&event)
+	return c.String(http.StatusOK, `{"success":"event has been deleted"}`)
+}
+
+// setEventPerformances replaces performances linked to event
+func setEventPerformances(c echo.Context) error {
+	var event model.Event
+	if err := db.First(&event, c.Param("id")).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.String(http.StatusNotFound, `{"error": "no such event"}`)
+	}
+	var ids model.EventPerformances
+	if err := c.Bind(&ids); err != nil {
+		return c.String(http.StatusBadRequest, `{"error": "bad request"}`)
+	}
+	performances := []model.Performance{}
+	if len(ids.IDs) > 0 {
+		db.Where("id IN (?)", ids.IDs).Find(&performances)
+	}
+	if len(performances) != len(ids.IDs) {
+		return c.String(http.StatusBadRequest, `{"error": "wrong performance ids"}`)
+	}
+	if err := db.Model(&event).Association("Performances").Replace(performances); err != nil {
+		return c.String(http.StatusInternalServerError, `{"error": "performances link error"}`)
+	}
+	event.Performances = performances
+	return c.JSON(http.StatusOK, event)
+}
@@ -0,0 +1,180 @@
+package poravkino
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eugenetolok/go-poravkino/pkg/feed"
+	"github.com/eugenetolok/go-poravkino/pkg/model"
+	"github.com/labstack/echo/v4"
+)
+
+// feeds caches encoded feeds by format between schedule syncs
+var feeds = struct {
+	sync.Mutex
+	data map[string][]byte
+}{data: map[string][]byte{}}
+
+// invalidateFeeds drops cached feeds, it is called after schedule sync
+func invalidateFeeds() {
+	feeds.Lock()
+	feeds.data = map[string][]byte{}
+	feeds.Unlock()
+}
+
+// feedPartner returns partner of key if it may get format
+func feedPartner(key, format string) (model.FeedPartner, bool) {
+	for _, partner := range appSettings.FeedSettings.Partners {
+		if partner.Key == "" || subtle.ConstantTimeCompare([]byte(partner.Key), []byte(key)) != 1 {
+			continue
+		}
+		if len(partner.Formats) == 0 {
+			return partner, true
+		}
+		for _, allowed := range partner.Formats {
+			if allowed == format {
+				return partner, true
+			}
+		}
+		return partner, false
+	}
+	return model.FeedPartner{}, false
+}
+
+// siteURL makes absolute url of site path
+func siteURL(path string) string {
+	if path == "" || strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	return fmt.Sprintf("https://%s%s", appSettings.CinemaSettings.DomainName, path)
+}
+
+// feedSchedule collects active movies and performances of feed days
+func feedSchedule() (feed.Schedule, error) {
+	days := appSettings.FeedSettings.Days
+	if days == 0 {
+		days = 14
+	}
+	cinema := appSettings.CinemaSettings
+	schedule := feed.Schedule{
+		Generated: cinemaNow(),
+		Cinema: feed.Cinema{
+			ID:      appSettings.BookingSettings.CinemaID,
+			Name:    cinema.CinemaName,
+			City:    cinema.CityName,
+			Address: cinema.Address,
+			URL:     siteURL("/"),
+		},
+		Movies:   []feed.Movie{},
+		Sessions: []feed.Session{},
+	}
+	var performances []model.Performance
+	err := db.Where("is_active = ? AND time > ? AND time < ?", true, time.Now(), cinemaDayStart(cinemaDate(time.Now()).AddDate(0, 0, int(days)))).
+		Order("time ASC").
+		Find(&performances).Error
+	if err != nil {
+		return schedule, err
+	}
+	var movieIDs []int64
+	for _, performance := range performances {
+		format := performance.Format
+		if format == "" {
+			format = "2D"
+			if performance.ThreeD {
+				format = "3D"
+			}
+		}
+		schedule.Sessions = append(schedule.Sessions, feed.Session{
+			ID:         performance.ID,
+			MovieID:    performance.MovieID,
+			Time:       cinemaTime(performance.Time),
+			Hall:       performance.HallName,
+			Format:     format,
+			Variant:    performance.Variant,
+			Price:      performance.Price,
+			SeatsFree:  performance.SeatsFree,
+			SeatsTotal: performance.SeatsTotal,
+			URL:        siteURL(fmt.Sprintf("/performance/%d", performance.ID)),
+		})
+		movieIDs = append(movieIDs, performance.MovieID)
+	}
+	if len(movieIDs) == 0 {
+		return schedule, nil
+	}
+	var movies []model.Movie
+	if err := db.Preload("GenreList").Where("id IN (?)", movieIDs).Order("index DESC, id ASC").Find(&movies).Error; err != nil {
+		return schedule, err
+	}
+	for _, movie := range movies {
+		item := feed.Movie{
+			ID:            movie.ID,
+			Title:         movie.Name,
+			OriginalTitle: movie.NameSecondary,
+			Age:           movie.Age,
+			Duration:      movie.Duration,
+			Genres:        []string{},
+			Description:   movie.Description,
+			Poster:        siteURL(movie.Poster),
+			Pushkin:       movie.IsPushkin,
+			URL:           siteURL(fmt.Sprintf("/movie/%d", movie.ID)),
+		}
+		if !movie.Premiere.IsZero() {
+			item.Premiere = cinemaTime(movie.Premiere).Format("2006-01-02")
+		}
+		for _, genre := range movie.GenreList {
+			item.Genres = append(item.Genres, genre.Name)
+		}
+		schedule.Movies = append(schedule.Movies, item)
+	}
+	return schedule, nil
+}
+
+// getFeed returns schedule feed for partner key from ?key= or X-API-Key header
+func getFeed(c echo.Context) error {
+	format := c.Param("format")
+	key := c.QueryParam("key")
+	if key == "" {
+		key = c.Request().Header.Get("X-API-Key")
+	}
+	partner, ok := feedPartner(key, format)
+	if partner.Key == "" {
+		return c.String(http.StatusUnauthorized, `{"error": "wrong key"}`)
+	}
+	if !ok {
+		return c.String(http.StatusForbidden, `{"error": "format is not allowed"}`)
+	}
+
+	data, err := cachedFeed(format)
+	if err == feed.ErrFormat {
+		return c.String(http.StatusNotFound, `{"error": "no such format"}`)
+	}
+	if err != nil {
+		log.Println("feed error:", err)
+		return c.String(http.StatusInternalServerError, `{"error": "internal server error"}`)
+	}
+	return c.Blob(http.StatusOK, feed.ContentType(format), data)
+}
+
+// cachedFeed returns cached feed or builds it, concurrent requests wait for one build
+func cachedFeed(format string) ([]byte, error) {
+	feeds.Lock()
+	defer feeds.Unlock()
+	if data, ok := feeds.data[format]; ok {
+		return data, nil
+	}
+	schedule, err := feedSchedule()
+	if err != nil {
+		return nil, err
+	}
+	data, err := feed.Encode(format, schedule)
+	if err != nil {
+		return nil, err
+	}
+	feeds.data[format] = data
+	return data, nil
+}
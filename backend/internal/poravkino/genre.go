@@ -0,0 +1,51 @@
+package poravkino
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/eugenetolok/go-poravkino/pkg/model"
+	"github.com/eugenetolok/go-poravkino/pkg/utils"
+	"github.com/labstack/echo/v4"
+)
+
+// syncMovieGenres links movie to normalized genres of free-text Genres
+func syncMovieGenres(movie *model.Movie) {
+	genres := []model.Genre{}
+	seen := map[string]bool{}
+	for _, name := range strings.FieldsFunc(movie.Genres, func(r rune) bool { return strings.ContainsRune(",;/|", r) }) {
+		name = strings.ToLower(strings.TrimSpace(name))
+		slug := utils.Slug(name)
+		if slug == "" || seen[slug] {
+			continue
+		}
+		seen[slug] = true
+		var genre model.Genre
+		if err := db.Where(model.Genre{Slug: slug}).Attrs(model.Genre{Name: name}).FirstOrCreate(&genre).Error; err != nil {
+			log.Println("genre error:", err)
+			continue
+		}
+		genres = append(genres, genre)
+	}
+	if err := db.Model(movie).Association("GenreList").Replace(genres); err != nil {
+		log.Println("movie genres error:", err)
+	}
+}
+
+// migrateGenres normalizes genres of all movies
+func migrateGenres() {
+	var movies []model.Movie
+	db.Where("genres <> ''").Find(&movies)
+	for i := range movies {
+		syncMovieGenres(&movies[i])
+	}
+	log.Printf("genres of %d movies are normalized", len(movies))
+}
+
+// getGenres returns all genres
+func getGenres(c echo.Context) error {
+	genres := []model.Genre{}
+	db.Order("name ASC").Find(&genres)
+	return c.JSON(http.StatusOK, genres)
+}
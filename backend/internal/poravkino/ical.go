@@ -0,0 +1,114 @@
+package poravkino
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/eugenetolok/go-poravkino/pkg/ical"
+	"github.com/eugenetolok/go-poravkino/pkg/model"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// getSaleICS returns calendar event for purchased performance
+func getSaleICS(c echo.Context) error {
+	var sale model.Sale
+	// refunded sales keep their event, it is sent as cancelled with the same UID
+	err := db.Preload("Performance.Movie").Where("secret = ?", c.QueryParam("secret")).Where("bank_order_status = ?", 2).First(&sale).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.String(http.StatusNotFound, `{"error": "продажа не найдена"}`)
+	}
+	if err != nil {
+		log.Println("ics sale error:", err)
+		return c.String(http.StatusInternalServerError, `{"error": "internal server error"}`)
+	}
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%d-%s.ics"`, sale.ExternalID, sale.Secret))
+	return c.Blob(http.StatusOK, ical.ContentType, saleCalendar(sale))
+}
+
+// scheduleICS returns public schedule feed, filtered by movie id and hall name
+func scheduleICS(c echo.Context) error {
+	var performances []model.Performance
+	query := db.Preload("Movie").
+		Where("is_active = ?", true).
+		Order("time ASC")
+	if movieID := c.QueryParam("movie"); movieID != "" {
+		query = query.Where("movie_id = ?", movieID)
+	}
+	if hall := c.QueryParam("hall"); hall != "" {
+		query = query.Where("hall_name = ?", hall)
+	}
+	if err := query.Find(&performances).Error; err != nil {
+		return c.String(http.StatusInternalServerError, `{"error": "internal server error"}`)
+	}
+	calendar := ical.Calendar{
+		ProdID: calendarProdID(),
+		Name:   appSettings.CinemaSettings.CinemaName,
+		Method: "PUBLISH",
+	}
+	for _, performance := range performances {
+		event := performanceEvent(performance)
+		event.UID = fmt.Sprintf("performance-%d@%s", performance.ExternalID, appSettings.CinemaSettings.DomainName)
+		event.URL = fmt.Sprintf("https://%s/performance/%d", appSettings.CinemaSettings.DomainName, performance.ID)
+		calendar.Events = append(calendar.Events, event)
+	}
+	return c.Blob(http.StatusOK, ical.ContentType, calendar.Encode())
+}
+
+// saleCalendar makes calendar with one event for sale
+func saleCalendar(sale model.Sale) []byte {
+	event := performanceEvent(sale.Performance)
+	event.UID = fmt.Sprintf("sale-%s@%s", sale.Secret, appSettings.CinemaSettings.DomainName)
+	event.URL = fmt.Sprintf("https://%s/api/sales/code/lost?secret=%s", appSettings.CinemaSettings.DomainName, sale.Secret)
+	var seats []string
+	for _, ticket := range sale.Tickets {
+		seats = append(seats, fmt.Sprintf("ряд %s, место %s", ticket.Row, ticket.Seat))
+	}
+	event.Description = fmt.Sprintf("Код: %d-%s\n%s\nЗал: %s\n%s",
+		sale.ExternalID, sale.Secret, event.Summary, sale.Performance.HallName, strings.Join(seats, "\n"))
+	event.Cancelled = sale.Refund
+	return ical.Calendar{
+		ProdID: calendarProdID(),
+		Method: "PUBLISH",
+		Events: []ical.Event{event},
+	}.Encode()
+}
+
+// performanceEvent fills event time, duration and place from performance
+func performanceEvent(performance model.Performance) ical.Event {
+	start := cinemaTime(performance.Time)
+	duration := time.Minute * time.Duration(performance.Movie.Duration+performance.Movie.AddDuration)
+	if duration <= 0 {
+		duration = 2 * time.Hour
+	}
+	summary := performance.Movie.NameSecondary
+	if summary == "" {
+		summary = performance.Movie.Name
+	}
+	if performance.ThreeD {
+		summary += " (3D)"
+	}
+	location := appSettings.CinemaSettings.CinemaName
+	if appSettings.CinemaSettings.Address != "" {
+		location += ", " + appSettings.CinemaSettings.Address
+	}
+	if performance.HallName != "" {
+		location += ", " + performance.HallName
+	}
+	return ical.Event{
+		Start:        start,
+		End:          start.Add(duration),
+		Summary:      summary,
+		Description:  fmt.Sprintf("%s\nЗал: %s", summary, performance.HallName),
+		Location:     location,
+		LastModified: performance.UpdatedAt,
+	}
+}
+
+func calendarProdID() string {
+	return fmt.Sprintf("-//%s//%s//RU", appSettings.CinemaSettings.CompanyName, appSettings.CinemaSettings.DomainName)
+}
@@ -1,71 +1,93 @@
 package poravkino
 
 import (
-	"fmt"
-	"image/jpeg"
+	"errors"
 	"log"
 	"net/http"
 	"os"
-	"path/filepath"
+	"strconv"
 
-	"github.com/esimov/stackblur-go"
-	"github.com/eugenetolok/go-poravkino/pkg/utils"
+	"github.com/eugenetolok/go-poravkino/pkg/imaging"
 	"github.com/labstack/echo/v4"
-	"github.com/nfnt/resize"
 )
 
+// imageStore keeps originals in upload storage and generated variants in local cache
+func imageStore() *imaging.Store {
+	return &imaging.Store{
+		Storage:  uploadStorage(),
+		CacheDir: workPath("cache/images"),
+	}
+}
+
+// downloadImage stores external image and returns its url, empty on error
+func downloadImage(url string) string {
+	path, err := imageStore().Download(url)
+	if err != nil {
+		log.Println("image download error:", err, "link:", url)
+		return ""
+	}
+	return path
+}
+
+// postImage stores jpeg, png or webp original
 func postImage(c echo.Context) error {
 	file, err := c.FormFile("file")
 	if err != nil {
-		return err
+		return c.String(http.StatusBadRequest, `{"error": "bad request"}`)
 	}
-
 	src, err := file.Open()
 	if err != nil {
 		return err
 	}
 	defer src.Close()
 
-	img, err := jpeg.Decode(src)
-	if err != nil {
-		fmt.Println(err.Error())
+	url, err := imageStore().Save(src)
+	if errors.Is(err, imaging.ErrFormat) {
+		return c.String(http.StatusBadRequest, `{"error": "only jpeg, png and webp are supported"}`)
 	}
-	bluredImg, err := stackblur.Process(img, 35)
-	if err != nil {
-		log.Println(err)
+	if errors.Is(err, imaging.ErrTooLarge) {
+		return c.String(http.StatusBadRequest, `{"error": "image is too large"}`)
 	}
-
-	// Create the file
-	err = os.MkdirAll(filepath.Join(utils.WorkDir(), "dist/uploads/"), os.ModePerm)
 	if err != nil {
-		log.Println(err)
+		log.Println("image upload error:", err)
+		return c.String(http.StatusInternalServerError, `{"error": "image upload error"}`)
 	}
+	return c.JSON(http.StatusOK, echo.Map{"url": url})
+}
 
-	name := utils.Sha1()
-	out, err := os.Create(filepath.Join(utils.WorkDir(), fmt.Sprintf("dist/uploads/%s.jpg", name)))
-	if err != nil {
-		log.Println(err)
+// getImage returns variant of image, e.g. /api/images/<hash>.jpg?w=400&h=600&fit=cover&blur=20
+func getImage(c echo.Context) error {
+	var o imaging.Options
+	for param, value := range map[string]*uint{"w": &o.Width, "h": &o.Height} {
+		if s := c.QueryParam(param); s != "" {
+			n, err := strconv.ParseUint(s, 10, 32)
+			if err != nil {
+				return c.String(http.StatusBadRequest, `{"error": "wrong `+param+`"}`)
+			}
+			*value = uint(n)
+		}
 	}
-	defer out.Close()
-
-	// resize to width 1920 using Lanczos resampling
-	// and preserve aspect ratio
-	m := resize.Resize(1920, 0, img, resize.Lanczos3)
-
-	// write new image to file
-	jpeg.Encode(out, m, nil)
+	if s := c.QueryParam("blur"); s != "" {
+		n, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return c.String(http.StatusBadRequest, `{"error": "wrong blur"}`)
+		}
+		o.Blur = uint32(n)
+	}
+	o.Fit = c.QueryParam("fit")
 
-	// blured part
-	outBlured, err := os.OpenFile(filepath.Join(utils.WorkDir(), "dist/uploads/"+"blured_"+name+".jpg"), os.O_WRONLY|os.O_CREATE, 0777)
+	data, contentType, err := imageStore().Variant(c.Param("name"), o)
+	if errors.Is(err, os.ErrNotExist) {
+		return c.String(http.StatusNotFound, `{"error": "no such image"}`)
+	}
+	if errors.Is(err, imaging.ErrOptions) {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
 	if err != nil {
-		log.Println(err)
+		log.Println("image variant error:", err)
+		return c.String(http.StatusInternalServerError, `{"error": "internal server error"}`)
 	}
-	// resize to width 1000 using Lanczos resampling
-	// and preserve aspect ratio
-	mBlured := resize.Resize(1920, 0, bluredImg, resize.Lanczos3)
-
-	// write new image to file
-	jpeg.Encode(outBlured, mBlured, nil)
-
-	return c.String(http.StatusOK, fmt.Sprintf(`{"url": "/uploads/%s.jpg"}`, name))
+	// name is content hash, so variant never changes
+	c.Response().Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	return c.Blob(http.StatusOK, contentType, data)
 }
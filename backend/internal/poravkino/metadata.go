@@ -0,0 +1,149 @@
+package poravkino
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/eugenetolok/go-poravkino/pkg/metadata"
+	"github.com/eugenetolok/go-poravkino/pkg/model"
+	"github.com/eugenetolok/go-poravkino/pkg/variant"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+const defaultMetadataCacheTTL = 7 * 24 * time.Hour
+
+// metadataChain builds providers from settings, providers without keys are skipped,
+// refresh bypasses cached responses
+func metadataChain(refresh bool) *metadata.Chain {
+	settings := appSettings.MetadataSettings
+	names := settings.Providers
+	if len(names) == 0 {
+		names = []string{"kinopoisk"}
+	}
+	ttl := time.Duration(settings.CacheTTLHours) * time.Hour
+	if ttl <= 0 {
+		ttl = defaultMetadataCacheTTL
+	}
+	chain := &metadata.Chain{Priority: settings.Priority}
+	for _, name := range names {
+		var provider metadata.Provider
+		switch name {
+		case "kinopoisk":
+			if appSettings.SiteSettings.KinopoiskAPI != "" {
+				provider = &metadata.Kinopoisk{APIKey: appSettings.SiteSettings.KinopoiskAPI}
+			}
+		case "tmdb":
+			if settings.TMDBAPIKey != "" {
+				provider = &metadata.TMDB{APIKey: settings.TMDBAPIKey}
+			}
+		case "file":
+			if settings.File != "" {
+				provider = &metadata.File{Path: workPath(settings.File)}
+			}
+		}
+		if provider == nil {
+			continue
+		}
+		if settings.CacheDir != "" && name != "file" {
+			provider = &metadata.Cached{Provider: provider, Dir: workPath(settings.CacheDir), TTL: ttl, Refresh: refresh}
+		}
+		chain.Providers = append(chain.Providers, provider)
+	}
+	return chain
+}
+
+// metadataQuery is movie name without variant markers
+func metadataQuery(movie model.Movie) string {
+	name := movie.NameSecondary
+	if name == "" {
+		name = movie.Name
+	}
+	title, _ := variant.Parse(name)
+	return title
+}
+
+// applyMetadata copies found values to movie, without overwrite only empty fields are filled
+func applyMetadata(movie *model.Movie, candidate metadata.Candidate, overwrite bool) {
+	fill := func(field *string, value string) {
+		if value != "" && (overwrite || *field == "") {
+			*field = value
+		}
+	}
+	fill(&movie.Description, candidate.Description)
+	fill(&movie.Country, metadata.Join(candidate.Countries))
+	fill(&movie.Genres, metadata.Join(candidate.Genres))
+	fill(&movie.Actors, metadata.Join(candidate.Actors))
+	fill(&movie.Director, metadata.Join(candidate.Directors))
+	if movie.Duration == 0 {
+		movie.Duration = candidate.Duration
+	}
+	if candidate.Poster != "" && (overwrite || movie.Poster == "") {
+		if poster := downloadImage(candidate.Poster); poster != "" {
+			movie.Poster = poster
+		}
+	}
+	if candidate.Backdrop != "" && (overwrite || movie.Backdrop == "") {
+		if backdrop := downloadImage(candidate.Backdrop); backdrop != "" {
+			movie.Backdrop = backdrop
+		}
+	}
+	movie.MetadataSource = candidate.Source
+	movie.MetadataID = candidate.ID
+}
+
+// movieMetadataCandidates returns matches of all providers for movie name or query
+func movieMetadataCandidates(c echo.Context) error {
+	var movie model.Movie
+	if err := db.First(&movie, c.Param("id")).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.String(http.StatusNotFound, `{"error": "no such movie"}`)
+	}
+	query := c.QueryParam("q")
+	if query == "" {
+		query = metadataQuery(movie)
+	}
+	candidates := metadataChain(false).Candidates(query)
+	if candidates == nil {
+		candidates = []metadata.Candidate{}
+	}
+	return c.JSON(http.StatusOK, candidates)
+}
+
+// MetadataChoice - chosen candidate, empty source means re-fetch through the whole chain
+type MetadataChoice struct {
+	Source string `json:"source"`
+	ID     string `json:"id"`
+}
+
+// refetchMovieMetadata overwrites movie metadata with chosen candidate or with fresh data
+func refetchMovieMetadata(c echo.Context) error {
+	var movie model.Movie
+	if err := db.First(&movie, c.Param("id")).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.String(http.StatusNotFound, `{"error": "no such movie"}`)
+	}
+	var choice MetadataChoice
+	if err := c.Bind(&choice); err != nil {
+		return c.String(http.StatusBadRequest, `{"error": "bad request"}`)
+	}
+	// manual re-fetch asks providers again and updates their cache
+	chain := metadataChain(true)
+	var candidate metadata.Candidate
+	var err error
+	if choice.Source != "" && choice.ID != "" {
+		provider, ok := chain.Provider(choice.Source)
+		if !ok {
+			return c.String(http.StatusBadRequest, `{"error": "no such metadata provider"}`)
+		}
+		candidate, err = provider.Get(choice.ID)
+	} else {
+		candidate, err = chain.Fetch(metadataQuery(movie))
+	}
+	if err != nil {
+		return c.String(http.StatusNotFound, `{"error": "metadata not found"}`)
+	}
+	applyMetadata(&movie, candidate, true)
+	db.Save(&movie)
+	syncMovieGenres(&movie)
+	return c.JSON(http.StatusOK, movie)
+}
@@ -3,7 +3,9 @@ package poravkino
 import (
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/eugenetolok/go-poravkino/pkg/model"
@@ -12,18 +14,28 @@ import (
 	"gorm.io/gorm"
 )
 
-// GetMovie returns movie by id
+// GetMovie returns movie by id, merged duplicates and variants return their movie
 func getMovie(c echo.Context) error {
 	movieID := c.Param("id")
 	var movie model.Movie
-	parsedDate, err := time.Parse("2006-01-02", c.QueryParam("date"))
+	if err := db.First(&movie, movieID).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.String(http.StatusNotFound, `{"error": "no such movie"}`)
+	}
+	movie = followMerges(movie)
+	if movie.ParentID != 0 {
+		movieID = fmt.Sprint(movie.ParentID)
+	} else {
+		movieID = fmt.Sprint(movie.ID)
+	}
+	movie = model.Movie{}
+	parsedDate, err := parseCinemaDate("2006-01-02", c.QueryParam("date"))
 	if err != nil {
-		parsedDate = time.Now()
+		parsedDate = cinemaDate(time.Now())
 	}
-	parsedDate = parsedDate.Add(time.Hour * time.Duration(6))
+	parsedDate = cinemaDayStart(parsedDate)
 	if err := db.Preload("Performances", func(db *gorm.DB) *gorm.DB {
-		return db.Order("performances.time ASC").Where("is_active = ?", true).Where("time BETWEEN ? AND ?", parsedDate, parsedDate.AddDate(0, 0, 1))
-	}).First(&movie, movieID).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return db.Order("performances.time ASC").Where("is_active = ?", true).Where("time >= ? AND time < ?", parsedDate, cinemaDayStart(parsedDate.AddDate(0, 0, 1)))
+	}).Preload("Trailers", orderedTrailers).Preload("GenreList").Preload("Tags").First(&movie, movieID).Error; errors.Is(err, gorm.ErrRecordNotFound) {
 		return c.String(http.StatusNotFound, `{"error": "no such movie"}`)
 	}
 	return c.JSON(http.StatusOK, movie)
@@ -43,14 +55,174 @@ func updateMovie(c echo.Context) error {
 	}
 	copier.Copy(&movie, &m)
 	db.Save(&movie)
+	syncMovieGenres(&movie)
 	return c.JSON(http.StatusOK, movie)
 }
 
-// GetMovies returns all movies
+// GetMovies returns all movies, filtered by ?tag=, ?genre= slugs and ?age_max=
 func getMovies(c echo.Context) error {
 	var movies []model.Movie
-	if err := db.Where("is_active = ?", true).Find(&movies).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+	query := db.Preload("Trailers", orderedTrailers).Preload("GenreList").Preload("Tags").
+		Where("is_active = ? AND merged_into_id = 0 AND parent_id = 0", true)
+	if tag := c.QueryParam("tag"); tag != "" {
+		query = query.Where("id IN (SELECT movie_tags.movie_id FROM movie_tags JOIN tags ON tags.id = movie_tags.tag_id WHERE tags.slug = ?)", tag)
+	}
+	if genre := c.QueryParam("genre"); genre != "" {
+		query = query.Where("id IN (SELECT movie_genres.movie_id FROM movie_genres JOIN genres ON genres.id = movie_genres.genre_id WHERE genres.slug = ?)", genre)
+	}
+	if ageMax, err := strconv.ParseInt(c.QueryParam("age_max"), 10, 64); err == nil {
+		query = query.Where("age <= ?", ageMax)
+	}
+	if err := query.Find(&movies).Error; errors.Is(err, gorm.ErrRecordNotFound) {
 		return c.String(http.StatusNotFound, `{"error": "no movies"}`)
 	}
 	return c.JSON(http.StatusOK, movies)
 }
+
+// allMovies returns movies for editors including inactive ones, q searches by name
+func allMovies(c echo.Context) error {
+	movies := []model.Movie{}
+	query := db.Order("id DESC")
+	if q := c.QueryParam("q"); q != "" {
+		query = query.Where("name ILIKE ? OR name_secondary ILIKE ?", "%"+q+"%", "%"+q+"%")
+	}
+	if c.QueryParam("merged") != "true" {
+		query = query.Where("merged_into_id = 0")
+	}
+	query.Find(&movies)
+	return c.JSON(http.StatusOK, movies)
+}
+
+// createMovie creates movie which is not known by booking system
+func createMovie(c echo.Context) error {
+	m := new(model.MovieIn)
+	if err := c.Bind(m); err != nil || m.Name == "" {
+		return c.String(http.StatusBadRequest, `{"error": "bad request"}`)
+	}
+	var movie model.Movie
+	copier.Copy(&movie, &m)
+	movie.IsManual = true
+	movie.IsActive = true
+	db.Create(&movie)
+	syncMovieGenres(&movie)
+	return c.JSON(http.StatusCreated, movie)
+}
+
+// deleteMovie deletes movie without performances, duplicates should be merged instead
+func deleteMovie(c echo.Context) error {
+	var movie model.Movie
+	if err := db.First(&movie, c.Param("id")).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.String(http.StatusNotFound, `{"error": "no such movie"}`)
+	}
+	var performances int64
+	db.Model(&model.Performance{}).Where("movie_id = ?", movie.ID).Count(&performances)
+	if performances > 0 {
+		return c.String(http.StatusConflict, `{"error": "movie has performances, merge it into other movie"}`)
+	}
+	db.Where("movie_id = ?", movie.ID).Delete(&model.Trailer{})
+	db.Model(&movie).Association("GenreList").Clear()
+	db.Model(&movie).Association("Tags").Clear()
+	db.Exec("DELETE FROM collection_movies WHERE movie_id = ?", movie.ID)
+	db.Model(&model.Event{}).Where("movie_id = ?", movie.ID).Update("movie_id", 0)
+	db.Delete(&movie)
+	return c.String(http.StatusOK, fmt.Sprintf(`{"success":"movie %d has been deleted"}`, movie.ID))
+}
+
+// mergeMovies moves performances, trailers and events of duplicates to movie,
+// empty fields of movie are filled from duplicates
+func mergeMovies(c echo.Context) error {
+	var movie model.Movie
+	if err := db.First(&movie, c.Param("id")).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.String(http.StatusNotFound, `{"error": "no such movie"}`)
+	}
+	if movie.MergedIntoID != 0 {
+		return c.String(http.StatusBadRequest, `{"error": "movie is merged into other movie"}`)
+	}
+	var merge model.MovieMerge
+	if err := c.Bind(&merge); err != nil || len(merge.IDs) == 0 {
+		return c.String(http.StatusBadRequest, `{"error": "bad request"}`)
+	}
+	var duplicates []model.Movie
+	db.Where("id IN (?) AND id <> ?", merge.IDs, movie.ID).Find(&duplicates)
+	if len(duplicates) != len(merge.IDs) {
+		return c.String(http.StatusBadRequest, `{"error": "wrong movie ids"}`)
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, duplicate := range duplicates {
+			for _, table := range []interface{}{&model.Performance{}, &model.Trailer{}, &model.Event{}} {
+				if err := tx.Model(table).Where("movie_id = ?", duplicate.ID).Update("movie_id", movie.ID).Error; err != nil {
+					return err
+				}
+			}
+			// earlier merges point to the new movie too
+			if err := tx.Model(&model.Movie{}).Where("merged_into_id = ?", duplicate.ID).Update("merged_into_id", movie.ID).Error; err != nil {
+				return err
+			}
+			fillMovie(&movie, duplicate)
+			movie.IsActive = movie.IsActive || duplicate.IsActive
+			if err := tx.Model(&duplicate).Updates(map[string]interface{}{"merged_into_id": movie.ID, "is_active": false}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Save(&movie).Error
+	})
+	if err != nil {
+		log.Println("movie merge error:", err)
+		return c.String(http.StatusInternalServerError, `{"error": "merge error"}`)
+	}
+	syncMovieGenres(&movie)
+	return c.JSON(http.StatusOK, movie)
+}
+
+// fillMovie copies non-empty fields of duplicate to empty fields of movie
+func fillMovie(movie *model.Movie, duplicate model.Movie) {
+	fill := func(field *string, value string) {
+		if *field == "" {
+			*field = value
+		}
+	}
+	fill(&movie.NameSecondary, duplicate.NameSecondary)
+	fill(&movie.Description, duplicate.Description)
+	fill(&movie.Genres, duplicate.Genres)
+	fill(&movie.Actors, duplicate.Actors)
+	fill(&movie.Country, duplicate.Country)
+	fill(&movie.Director, duplicate.Director)
+	fill(&movie.Poster, duplicate.Poster)
+	fill(&movie.Backdrop, duplicate.Backdrop)
+	fill(&movie.RentCertificate, duplicate.RentCertificate)
+	if movie.Duration == 0 {
+		movie.Duration = duplicate.Duration
+	}
+	if movie.Premiere.IsZero() {
+		movie.Premiere = duplicate.Premiere
+	}
+	movie.IsPushkin = movie.IsPushkin || duplicate.IsPushkin
+}
+
+// followMerges returns movie which duplicate was merged into
+func followMerges(movie model.Movie) model.Movie {
+	for i := 0; movie.MergedIntoID != 0 && i < 10; i++ {
+		var target model.Movie
+		if err := db.First(&target, movie.MergedIntoID).Error; err != nil {
+			break
+		}
+		movie = target
+	}
+	return movie
+}
+
+// movieDefaults are columns added to movies after first release, existing rows get NULL in them,
+// show_in_upcoming has no database default, as GORM would store it instead of false on create
+var movieDefaults = map[string]interface{}{
+	"merged_into_id":   0,
+	"is_manual":        false,
+	"parent_id":        0,
+	"title_key":        "",
+	"show_in_upcoming": true,
+}
+
+// migrateMovies fills NULL columns of existing movies, it runs before NOT NULL constraints
+// are added and after new columns are created
+func migrateMovies() {
+	fillNullColumns(&model.Movie{}, movieDefaults)
+}
@@ -3,6 +3,8 @@ package poravkino
 import (
 	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/eugenetolok/go-poravkino/pkg/model"
 	"github.com/jinzhu/copier"
@@ -10,12 +12,91 @@ import (
 	"gorm.io/gorm"
 )
 
+// notifications returns currently published notifications for page context,
+// e.g. ?context=movie&movie_id=5, ?context=checkout, ?type=popup
 func notifications(c echo.Context) error {
-	var notifications []model.Notification
-	db.Find(&notifications)
+	notifications := []model.Notification{}
+	now := time.Now()
+	cinemaID := appSettings.BookingSettings.CinemaID
+	if id, err := strconv.ParseInt(c.QueryParam("cinema_id"), 10, 64); err == nil {
+		cinemaID = id
+	}
+	audience := db.Where("audience = ?", model.AudienceAll).
+		Or("audience = ? AND audience_id = ?", model.AudienceCinema, cinemaID)
+	switch c.QueryParam("context") {
+	case model.AudienceMovie:
+		movieID, _ := strconv.ParseInt(c.QueryParam("movie_id"), 10, 64)
+		audience = audience.Or("audience = ? AND audience_id = ?", model.AudienceMovie, movieID)
+	case model.AudienceCheckout:
+		audience = audience.Or("audience = ?", model.AudienceCheckout)
+	}
+	query := db.Where("publish_at <= ?", now).
+		Where("expire_at = ? OR expire_at > ?", time.Time{}, now).
+		Where(audience)
+	if notificationType := c.QueryParam("type"); notificationType != "" {
+		query = query.Where("type = ?", notificationType)
+	}
+	query.Order(`"order" ASC, id DESC`).Find(&notifications)
 	return c.JSON(http.StatusOK, notifications)
 }
 
+// allNotifications returns all notifications for editors
+func allNotifications(c echo.Context) error {
+	notifications := []model.Notification{}
+	db.Order(`"order" ASC, id DESC`).Find(&notifications)
+	return c.JSON(http.StatusOK, notifications)
+}
+
+// checkNotification sets default type and audience and checks values
+func checkNotification(notification *model.Notification) bool {
+	if notification.Type == "" {
+		notification.Type = model.NotificationBanner
+		if notification.IsInSlider {
+			notification.Type = model.NotificationSlider
+		}
+	}
+	notification.IsInSlider = notification.Type == model.NotificationSlider
+	if notification.Audience == "" {
+		notification.Audience = model.AudienceAll
+	}
+	switch notification.Type {
+	case model.NotificationBanner, model.NotificationPopup, model.NotificationSlider:
+	default:
+		return false
+	}
+	switch notification.Audience {
+	case model.AudienceAll, model.AudienceCheckout:
+	case model.AudienceMovie, model.AudienceCinema:
+		if notification.AudienceID == 0 {
+			return false
+		}
+	default:
+		return false
+	}
+	return notification.ExpireAt.IsZero() || notification.ExpireAt.After(notification.PublishAt)
+}
+
+// notificationDefaults are columns added to notifications after first release,
+// type is set from is_in_slider by migrateNotifications
+var notificationDefaults = map[string]interface{}{
+	"type":        "",
+	"audience":    model.AudienceAll,
+	"audience_id": 0,
+	"publish_at":  gorm.Expr("created_at"),
+	"expire_at":   time.Time{},
+}
+
+// migrateNotifications sets type of notifications created before it,
+// new column gets banner default, so legacy sliders are fixed too
+func migrateNotifications() {
+	db.Model(&model.Notification{}).
+		Where("(type IS NULL OR type = '' OR type = ?) AND is_in_slider = ?", model.NotificationBanner, true).
+		Update("type", model.NotificationSlider)
+	db.Model(&model.Notification{}).Where("type IS NULL OR type = ''").Update("type", model.NotificationBanner)
+	db.Model(&model.Notification{}).Where("audience IS NULL OR audience = ''").Update("audience", model.AudienceAll)
+	db.Model(&model.Notification{}).Where("publish_at IS NULL").Update("publish_at", gorm.Expr("created_at"))
+}
+
 // getNotification
 func getNotification(c echo.Context) error {
 	id := c.Param("id")
@@ -38,6 +119,9 @@ func updateNotification(c echo.Context) error {
 		return c.String(http.StatusBadRequest, `{"error": "bad request"}`)
 	}
 	copier.Copy(&notification, &m)
+	if !checkNotification(&notification) {
+		return c.String(http.StatusBadRequest, `{"error": "wrong type, audience or dates"}`)
+	}
 	db.Save(&notification)
 	return c.JSON(http.StatusOK, notification)
 }
@@ -50,6 +134,9 @@ func createNotification(c echo.Context) error {
 	}
 	var notification model.Notification
 	copier.Copy(&notification, &notificationIn)
+	if !checkNotification(&notification) {
+		return c.String(http.StatusBadRequest, `{"error": "wrong type, audience or dates"}`)
+	}
 	db.Save(&notification)
 	return c.JSON(http.StatusCreated, notification)
 }
@@ -0,0 +1,247 @@
+package poravkino
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/eugenetolok/go-poravkino/pkg/markdown"
+	"github.com/eugenetolok/go-poravkino/pkg/model"
+	"github.com/eugenetolok/go-poravkino/pkg/utils"
+	"github.com/jinzhu/copier"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// renderPage fills html of page body
+func renderPage(page *model.Page) {
+	if page.Format == model.PageMarkdown {
+		page.HTML = markdown.Render(page.Body)
+		return
+	}
+	page.HTML = page.Body
+}
+
+// getPage returns published page by slug
+func getPage(c echo.Context) error {
+	var page model.Page
+	if err := db.Where("slug = ? AND is_published = ? AND link_url = ''", c.Param("slug"), true).First(&page).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.String(http.StatusNotFound, `{"error": "no such page"}`)
+	}
+	renderPage(&page)
+	return c.JSON(http.StatusOK, page)
+}
+
+// getMenu returns menu items of published pages
+func getMenu(c echo.Context) error {
+	var pages []model.Page
+	db.Where("is_published = ? AND menu <> ''", true).Order("menu_order ASC, id ASC").Find(&pages)
+	items := []model.MenuItem{}
+	for _, page := range pages {
+		item := model.MenuItem{Title: page.MenuTitle, URL: page.LinkURL, Menu: page.Menu}
+		if item.Title == "" {
+			item.Title = page.Title
+		}
+		if item.URL == "" {
+			item.URL = "/page/" + page.Slug
+		}
+		items = append(items, item)
+	}
+	return c.JSON(http.StatusOK, items)
+}
+
+// allPages returns pages for editors
+func allPages(c echo.Context) error {
+	pages := []model.Page{}
+	db.Order("menu_order ASC, id ASC").Find(&pages)
+	return c.JSON(http.StatusOK, pages)
+}
+
+// bindPage binds page, slug is made from title if empty
+func bindPage(c echo.Context, page *model.Page) bool {
+	var pageIn model.PageIn
+	if err := c.Bind(&pageIn); err != nil || pageIn.Title == "" {
+		return false
+	}
+	copier.Copy(page, &pageIn)
+	return checkPage(page)
+}
+
+// checkPage sets defaults of page and checks format and menu
+func checkPage(page *model.Page) bool {
+	if page.Slug == "" {
+		page.Slug = utils.Slug(page.Title)
+	}
+	if page.Format == "" {
+		page.Format = model.PageHTML
+	}
+	switch page.Menu {
+	case model.MenuNone, model.MenuTop, model.MenuPopup:
+	default:
+		return false
+	}
+	return page.Slug != "" && (page.Format == model.PageHTML || page.Format == model.PageMarkdown)
+}
+
+// savePage saves page with new revision
+func savePage(page *model.Page, authorID uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		page.Version++
+		if err := tx.Save(page).Error; err != nil {
+			return err
+		}
+		revision := model.PageRevision{PageID: page.ID, Version: page.Version, AuthorID: authorID}
+		copier.Copy(&revision.PageIn, page)
+		return tx.Create(&revision).Error
+	})
+}
+
+// createPage creates page
+func createPage(c echo.Context) error {
+	var page model.Page
+	if !bindPage(c, &page) {
+		return c.String(http.StatusBadRequest, `{"error": "bad request"}`)
+	}
+	userID, _ := utils.GetUser(c)
+	if err := savePage(&page, userID); err != nil {
+		return c.String(http.StatusConflict, `{"error": "page already exists"}`)
+	}
+	renderPage(&page)
+	return c.JSON(http.StatusCreated, page)
+}
+
+// updatePage saves new version of page
+func updatePage(c echo.Context) error {
+	var page model.Page
+	if err := db.First(&page, c.Param("id")).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.String(http.StatusNotFound, `{"error": "no such page"}`)
+	}
+	if !bindPage(c, &page) {
+		return c.String(http.StatusBadRequest, `{"error": "bad request"}`)
+	}
+	userID, _ := utils.GetUser(c)
+	if err := savePage(&page, userID); err != nil {
+		return c.String(http.StatusConflict, `{"error": "page already exists"}`)
+	}
+	renderPage(&page)
+	return c.JSON(http.StatusOK, page)
+}
+
+// deletePage deletes page with its revisions
+func deletePage(c echo.Context) error {
+	var page model.Page
+	if err := db.First(&page, c.Param("id")).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.String(http.StatusNotFound, `{"error": "no such page"}`)
+	}
+	db.Where("page_id = ?", page.ID).Delete(&model.PageRevision{})
+	db.Delete(&page)
+	return c.String(http.StatusOK, fmt.Sprintf(`{"success":"page %d has been deleted"}`, page.ID))
+}
+
+// pageRevisions returns revisions of page, the latest first
+func pageRevisions(c echo.Context) error {
+	revisions := []model.PageRevision{}
+	db.Where("page_id = ?", c.Param("id")).Order("version DESC").Find(&revisions)
+	return c.JSON(http.StatusOK, revisions)
+}
+
+// rollbackPage saves content of old revision as the new version
+func rollbackPage(c echo.Context) error {
+	var page model.Page
+	if err := db.First(&page, c.Param("id")).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.String(http.StatusNotFound, `{"error": "no such page"}`)
+	}
+	var revision model.PageRevision
+	if err := db.Where("page_id = ? AND version = ?", page.ID, c.Param("version")).First(&revision).Error; err != nil {
+		return c.String(http.StatusNotFound, `{"error": "no such revision"}`)
+	}
+	copier.Copy(&page, &revision.PageIn)
+	userID, _ := utils.GetUser(c)
+	if err := savePage(&page, userID); err != nil {
+		return c.String(http.StatusConflict, `{"error": "page already exists"}`)
+	}
+	renderPage(&page)
+	return c.JSON(http.StatusOK, page)
+}
+
+// importPages creates pages from cinema settings of app.yaml, existing slugs are skipped
+func importPages() {
+	cinema := appSettings.CinemaSettings
+	pages := []model.Page{
+		{
+			Slug:        "about",
+			Title:       "О кинотеатре",
+			Body:        cinema.AboutHTML,
+			Carousel:    cinema.AboutCarousel,
+			Menu:        model.MenuTop,
+			IsPublished: cinema.AboutHTML != "",
+		},
+		{
+			Slug:        "contacts",
+			Title:       "Контакты",
+			Body:        contactsHTML(cinema),
+			Menu:        model.MenuTop,
+			MenuOrder:   1,
+			IsPublished: true,
+		},
+	}
+	for i, item := range cinema.Menu {
+		page := model.Page{
+			Title:       item.Title,
+			LinkURL:     item.URL,
+			Menu:        model.MenuTop,
+			MenuOrder:   int64(2 + i),
+			IsPublished: true,
+		}
+		if item.IsInPopup {
+			page.Menu = model.MenuPopup
+		}
+		page.Slug = "menu-" + utils.Slug(item.Title)
+		pages = append(pages, page)
+	}
+	var imported int
+	for _, page := range pages {
+		if !checkPage(&page) {
+			continue
+		}
+		var count int64
+		db.Model(&model.Page{}).Where("slug = ?", page.Slug).Count(&count)
+		if count > 0 {
+			continue
+		}
+		if err := savePage(&page, 0); err != nil {
+			log.Println("page import error:", page.Slug, err)
+			continue
+		}
+		imported++
+	}
+	log.Printf("%d pages are imported from app.yaml", imported)
+}
+
+// contactsHTML makes contacts page from contact fields of settings
+func contactsHTML(cinema model.CinemaSettings) string {
+	var b strings.Builder
+	row := func(label, value string) {
+		if value != "" {
+			b.WriteString("<p><b>" + html.EscapeString(label) + ":</b> " + html.EscapeString(value) + "</p>\n")
+		}
+	}
+	row("Адрес", cinema.Address)
+	row("Поддержка", cinema.Support)
+	job := cinema.ContactPersonJob
+	if job == "" {
+		job = "Контактное лицо"
+	}
+	row(job, cinema.ContactPerson)
+	row("Связь", cinema.ContactPersonConnection)
+	if cinema.ContactPersonTip != "" {
+		b.WriteString("<p>" + html.EscapeString(cinema.ContactPersonTip) + "</p>\n")
+	}
+	if cinema.MapURL != "" {
+		b.WriteString(`<p><a href="` + html.EscapeString(cinema.MapURL) + `">Как добраться</a></p>` + "\n")
+	}
+	return b.String()
+}
@@ -20,20 +20,46 @@ const maxPlacesPerSale = 5
 func newSale(c echo.Context) error {
 	var preSale model.PreSale
 	if err := c.Bind(&preSale); err != nil {
-		return c.String(http.StatusBadRequest, fmt.Sprintf("err: %s", err.Error()))
+		return c.String(http.StatusBadRequest, `{"error": "bad request"}`)
 	}
-	if len(preSale.Places) > maxPlacesPerSale || len(preSale.Places) <= 0 {
+	sale, err := createSale(c, preSale)
+	switch err {
+	case nil:
+		return c.JSON(http.StatusOK, model.PaymentURL{URL: sale.BankPaymentForm})
+	case errSalePlaces:
 		return c.String(http.StatusBadRequest, `{"error": "bad request, wrong length"}`)
+	case errSalePerformance:
+		return c.String(http.StatusNotFound, `{"error": "no such performance"}`)
+	case errSalePushkin:
+		return c.String(http.StatusBadRequest, `{"error": "this performance is not pushkin"}`)
+	case errBookingPlaces:
+		return c.String(http.StatusNotFound, `{"error": "booking system doesn't accept places"}`)
+	}
+	return c.String(http.StatusInternalServerError, `{"error": "Payment system doesn't accept payment"}`)
+}
+
+var (
+	errSalePlaces      = errors.New("wrong number of places")
+	errSalePerformance = errors.New("no such performance")
+	errSalePushkin     = errors.New("performance is not pushkin")
+	errBookingPlaces   = errors.New("booking system doesn't accept places")
+	errPaymentForm     = errors.New("payment system doesn't accept payment")
+)
+
+// createSale books places and creates payment, errors are shown by v1 and v2 handlers
+func createSale(c echo.Context, preSale model.PreSale) (model.Sale, error) {
+	var sale model.Sale
+	if len(preSale.Places) > maxPlacesPerSale || len(preSale.Places) <= 0 {
+		return sale, errSalePlaces
 	}
 
 	var performance model.Performance
 	if err := db.Preload("Movie").First(&performance, preSale.PerformanceID).Where("is_active = ?", true).Error; errors.Is(err, gorm.ErrRecordNotFound) {
-		return c.String(http.StatusNotFound, `{"error": "no such performance"}`)
+		return sale, errSalePerformance
 	}
 	if preSale.Pushkin && !performance.Movie.IsPushkin {
-		return c.String(http.StatusBadRequest, `{"error": "this performance is not pushkin"}`)
+		return sale, errSalePushkin
 	}
-	var sale model.Sale
 	sale.ExternalPerformanceID = performance.ExternalID
 	sale.PerformanceID = int64(performance.ID)
 	sale.Secret = utils.Sha1()
@@ -45,7 +71,7 @@ func newSale(c echo.Context) error {
 	err := extapi.CreateSale(preSale, &sale)
 	if err != nil {
 		extapi.RemoveSale(&sale)
-		return c.String(http.StatusNotFound, `{"error": "booking system doesn't accept places"}`)
+		return sale, errBookingPlaces
 	}
 	sale.Email = preSale.Email
 	var form string
@@ -53,11 +79,11 @@ func newSale(c echo.Context) error {
 	if err != nil {
 		extapi.RemoveSale(&sale)
 		fmt.Println("error is in", err.Error())
-		return c.JSON(http.StatusInternalServerError, `{"error": "Payment system doesn't accept payment"}`)
+		return sale, errPaymentForm
 	}
 	sale.BankPaymentForm = form
 	db.Save(&sale)
-	return c.String(http.StatusOK, fmt.Sprintf(`{"url":"%s"}`, sale.BankPaymentForm))
+	return sale, nil
 }
 
 // func paymentGateway(c echo.Context) error {
@@ -93,6 +119,7 @@ func checkSale(c echo.Context) error {
 	if !extapi.ApproveSale(&sale) {
 		sale.ProblemStep = 2
 		db.Save(&sale)
+		alert(fmt.Sprintf("Ошибка подтверждения оплаченной продажи %d-%s", sale.ExternalID, sale.Secret))
 		return c.Redirect(http.StatusTemporaryRedirect, fmt.Sprintf("/api/sales/processing?token=%s", sale.Secret))
 	}
 	db.Save(&sale)
@@ -110,18 +137,39 @@ func returnSale(c echo.Context) error {
 	if err := db.Where("external_id", c.Param("id")).First(&sale).Error; errors.Is(err, gorm.ErrRecordNotFound) {
 		return c.String(http.StatusNotFound, `{"error": "no such sale"}`)
 	}
-	if !extapi.RemoveSale(&sale) {
+	switch refundSale(&sale) {
+	case errBookingRefund:
 		return c.String(http.StatusInternalServerError, `{"error": "booking system error on sale removal"}`)
-	}
-	if !yookassa.Return(&sale) {
+	case errPaymentRefund:
 		return c.String(http.StatusInternalServerError, `{"error": "payment system doesn't accept return on sale removal"}`)
 	}
 	log.Println("Success of returning sale:", sale.ID)
-	sale.Refund = true
-	db.Save(&sale)
 	return c.JSON(http.StatusOK, sale)
 }
 
+var (
+	errBookingRefund = errors.New("booking system error on sale removal")
+	errPaymentRefund = errors.New("payment system doesn't accept return on sale removal")
+)
+
+// refundSale removes sale in booking system and returns money
+func refundSale(sale *model.Sale) error {
+	if !extapi.RemoveSale(sale) {
+		alert(fmt.Sprintf("Ошибка возврата в системе бронирования, продажа %d-%s", sale.ExternalID, sale.Secret))
+		return errBookingRefund
+	}
+	if !yookassa.Return(sale) {
+		alert(fmt.Sprintf("Ошибка возврата в платежной системе, продажа %d-%s", sale.ExternalID, sale.Secret))
+		return errPaymentRefund
+	}
+	sale.Refund = true
+	// refund email is queued for sendRefundEmails
+	sale.RefundEmailSent = false
+	db.Save(sale)
+	sendRefundSMS(*sale)
+	return nil
+}
+
 func selfRefund(c echo.Context) error {
 	var sale model.Sale
 	secret := c.QueryParam("secret")
@@ -129,22 +177,17 @@ func selfRefund(c echo.Context) error {
 	if err := db.Preload("Performance").Where("secret = ?", secret).First(&sale).Error; errors.Is(err, gorm.ErrRecordNotFound) {
 		return c.String(http.StatusNotFound, `{"error": "продажа не найдена"}`)
 	}
-	currentTime := time.Now().Add(time.Hour * time.Duration(appSettings.SiteSettings.TimeZoneOffset)).UTC()
-	currentTime = currentTime.Add(time.Minute * 30)
-	fmt.Println("perf time:", sale.Performance.Time.UTC())
-	fmt.Println("self time:", currentTime)
-	if !sale.Performance.Time.After(currentTime.UTC()) {
+	currentTime := time.Now().Add(time.Minute * 30)
+	if !sale.Performance.Time.After(currentTime) {
 		return c.String(http.StatusBadRequest, `{"error": "запрос сделан позднее чем за 30 минут до начала сеанса"}`)
 	}
-	if !extapi.RemoveSale(&sale) {
+	switch refundSale(&sale) {
+	case errBookingRefund:
 		return c.String(http.StatusInternalServerError, `{"error": "билеты были распечатаны или пользовательский возврат заблокирован"}`)
-	}
-	if !yookassa.Return(&sale) {
+	case errPaymentRefund:
 		return c.String(http.StatusInternalServerError, `{"error": "ошибка возврата в платежной системе"}`)
 	}
 	log.Println("Self refund:", sale.Secret)
-	sale.Refund = true
-	db.Save(&sale)
 	return c.String(http.StatusOK, `{"message": "запрос выполнен"}`)
 }
 
@@ -174,6 +217,11 @@ func updateSales() {
 				log.Println("Success sale! Secret:", sale.Secret)
 			} else {
 				log.Println("Extapi sale approve error, secret:", sale.Secret)
+				// sale stays unpaid in db, so approve is retried, failure is alerted once
+				if sale.ProblemStep < 2 {
+					db.Model(&sale).Update("problem_step", 2)
+					alert(fmt.Sprintf("Ошибка подтверждения оплаченной продажи %d-%s", sale.ExternalID, sale.Secret))
+				}
 			}
 		}
 	}
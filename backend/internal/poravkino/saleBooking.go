@@ -100,8 +100,8 @@ func searchSale(c echo.Context) error {
 	)
 	c.Bind(&searchSale)
 	searchSale.Query = strings.ToLower("%" + searchSale.Query + "%")
-	from, _ := time.Parse("2006-01-02", searchSale.DateFrom)
-	to, _ := time.Parse("2006-01-02", searchSale.DateTo)
+	from, _ := parseCinemaDate("2006-01-02", searchSale.DateFrom)
+	to, _ := parseCinemaDate("2006-01-02", searchSale.DateTo)
 
 	if err := db.Preload("Performance.Movie").
 		Where("(created_at BETWEEN ? AND ?) AND (secret LIKE ? OR email LIKE ? OR phone LIKE ? OR CAST(external_id AS TEXT) LIKE ?)",
@@ -124,7 +124,7 @@ func searchSale(c echo.Context) error {
 //  	2.2.2 If sber -> try 5 times to reach sberbank
 //  	2.2.3 If booking -> try 5 times to reach booking
 // 3 Answer to client
-// 	3.1 If error -> send message to telegram chanel
+// 	3.1 If error -> send message to telegram chanel (see alert)
 //  3.2 If success -> redirect to tickets
 
 func processing(c echo.Context) error {
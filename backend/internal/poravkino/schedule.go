@@ -5,37 +5,130 @@ import (
 	"log"
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/eugenetolok/go-poravkino/pkg/extapi"
 	"github.com/eugenetolok/go-poravkino/pkg/model"
-	"github.com/eugenetolok/go-poravkino/pkg/utils"
 	"github.com/labstack/echo/v4"
 	"gorm.io/gorm"
 )
 
+// maxScheduleDays limits date range of schedule and calendar
+const maxScheduleDays = 62
+
+// scheduleRange parses ?date or ?from&to (both inclusive) to bounds of schedule days,
+// defaultDays from today are used if both are empty and defaultDays is set
+func scheduleRange(c echo.Context, defaultDays int) (time.Time, time.Time, error) {
+	from, to := c.QueryParam("from"), c.QueryParam("to")
+	if date := c.QueryParam("date"); date != "" {
+		from, to = date, date
+	}
+	var start, end time.Time
+	var err error
+	if from == "" && defaultDays > 0 {
+		start = cinemaDate(time.Now())
+	} else if start, err = parseCinemaDate("2006-01-02", from); err != nil {
+		return start, end, err
+	}
+	if to == "" && defaultDays > 0 {
+		end = start.AddDate(0, 0, defaultDays-1)
+	} else if end, err = parseCinemaDate("2006-01-02", to); err != nil {
+		return start, end, err
+	}
+	if end.Before(start) || !end.Before(start.AddDate(0, 0, maxScheduleDays)) {
+		return start, end, errors.New("wrong date range")
+	}
+	return cinemaDayStart(start), cinemaDayStart(end.AddDate(0, 0, 1)), nil
+}
+
+// scheduleFilters filters performances by query in addition to attributes, e.g.
+// ?hall=Зал 1&is3d=true&time_from=18:00&time_to=23:00&price_max=400&age_max=12&genre=komediya&pushkin=true
+func scheduleFilters(c echo.Context) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Scopes(performanceFilters(c))
+		if hall := c.QueryParam("hall"); hall != "" {
+			db = db.Where("performances.hall_name = ?", hall)
+		}
+		if threeD, err := strconv.ParseBool(c.QueryParam("is3d")); err == nil {
+			db = db.Where("performances.three_d = ?", threeD)
+		}
+		// minutes since day start, so night performances are after evening ones
+		minutes := "EXTRACT(EPOCH FROM CAST((performances.time AT TIME ZONE ?) - ? * INTERVAL '1 minute' AS time)) / 60"
+		if from, ok := dayMinutes(c.QueryParam("time_from")); ok {
+			db = db.Where(minutes+" >= ?", cinemaZone(), dayStart().Minutes(), from)
+		}
+		if to, ok := dayMinutes(c.QueryParam("time_to")); ok {
+			db = db.Where(minutes+" <= ?", cinemaZone(), dayStart().Minutes(), to)
+		}
+		if priceMax, err := strconv.ParseInt(c.QueryParam("price_max"), 10, 64); err == nil {
+			db = db.Where("performances.price <= ?", priceMax)
+		}
+		if ageMax, err := strconv.ParseInt(c.QueryParam("age_max"), 10, 64); err == nil {
+			db = db.Where("performances.movie_id IN (SELECT id FROM movies WHERE age <= ?)", ageMax)
+		}
+		if pushkin, err := strconv.ParseBool(c.QueryParam("pushkin")); err == nil {
+			db = db.Where("performances.movie_id IN (SELECT id FROM movies WHERE is_pushkin = ?)", pushkin)
+		}
+		if genre := c.QueryParam("genre"); genre != "" {
+			db = db.Where("performances.movie_id IN (SELECT movie_genres.movie_id FROM movie_genres JOIN genres ON genres.id = movie_genres.genre_id WHERE genres.slug = ?)", genre)
+		}
+		return db
+	}
+}
+
+// dayMinutes parses HH:MM to minutes since schedule day start
+func dayMinutes(value string) (int, bool) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, false
+	}
+	return (t.Hour()*60 + t.Minute() - int(dayStart().Minutes()) + 24*60) % (24 * 60), true
+}
+
+// scheduleOrders - ?sort values of schedule grouped by movie
+var scheduleOrders = map[string]string{
+	"":      "movies.index DESC, COUNT(performances.id) DESC",
+	"count": "COUNT(performances.id) DESC, movies.index DESC",
+	"name":  "movies.name ASC",
+	"time":  "MIN(performances.time) ASC, movies.index DESC",
+	"price": "MIN(performances.price) ASC, movies.index DESC",
+}
+
+// schedule returns movies with performances of ?date or of ?from&to range,
+// ?group=hall or ?group=time returns performances grouped by hall or by hour
 func schedule(c echo.Context) error {
 	var movies []model.Movie
-	parsedDate, err := time.Parse("2006-01-02", c.QueryParam("date"))
+	parsedDate, nextDate, err := scheduleRange(c, 0)
 	if err != nil {
 		return c.String(http.StatusBadRequest, `{"error": "wrong date"}`)
 	}
-	parsedDate = parsedDate.Add(time.Hour * time.Duration(6))
-	nextDate := parsedDate.AddDate(0, 0, 1)
+	switch c.QueryParam("group") {
+	case "", "movie":
+	case "hall", "time":
+		return scheduleGroups(c, parsedDate, nextDate)
+	default:
+		return c.String(http.StatusBadRequest, `{"error": "wrong group"}`)
+	}
+	order, ok := scheduleOrders[c.QueryParam("sort")]
+	if !ok {
+		return c.String(http.StatusBadRequest, `{"error": "wrong sort"}`)
+	}
 
 	err = db.Table("movies").
 		Select("movies.*, COUNT(performances.id) AS performance_count").
 		Joins("JOIN performances ON movies.id = performances.movie_id").
 		Where("movies.is_active = ?", true).
+		Where("movies.merged_into_id = 0 AND movies.parent_id = 0").
 		Where("performances.is_active = ?", true).
 		Where("performances.time > ?", time.Now()).
-		Where("performances.time BETWEEN ? AND ?", parsedDate, nextDate).
+		Where("performances.time >= ? AND performances.time < ?", parsedDate, nextDate).
+		Scopes(scheduleFilters(c)).
 		Group("movies.id").
-		Order("movies.index DESC, COUNT(performances.id) DESC").
+		Order(order).
 		Preload("Performances", func(db *gorm.DB) *gorm.DB {
-			return db.Where("is_active = ? AND time > ? AND time BETWEEN ? AND ?", true, time.Now(), parsedDate, nextDate).Order("time ASC")
+			return db.Where("is_active = ? AND time > ? AND time >= ? AND time < ?", true, time.Now(), parsedDate, nextDate).Scopes(scheduleFilters(c)).Order("time ASC")
 		}).
+		Preload("Trailers", orderedTrailers).
 		Find(&movies).Error
 
 	if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -49,22 +142,96 @@ func schedule(c echo.Context) error {
 	return c.JSON(http.StatusOK, movies)
 }
 
+// scheduleGroups returns performances with their movies grouped by hall or by hour
+func scheduleGroups(c echo.Context, start, end time.Time) error {
+	var performances []model.Performance
+	order := "performances.time ASC, performances.hall_name ASC"
+	if c.QueryParam("group") == "hall" {
+		order = "performances.hall_name ASC, performances.time ASC"
+	}
+	err := db.Joins("Movie").
+		Where("\"Movie\".is_active = ?", true).
+		Where("performances.is_active = ?", true).
+		Where("performances.time > ?", time.Now()).
+		Where("performances.time >= ? AND performances.time < ?", start, end).
+		Scopes(scheduleFilters(c)).
+		Order(order).
+		Find(&performances).Error
+	if err != nil {
+		return c.String(http.StatusInternalServerError, `{"error": "internal server error"}`)
+	}
+	groups := []model.ScheduleGroup{}
+	for _, performance := range performances {
+		title := performance.HallName
+		if c.QueryParam("group") == "time" {
+			layout := "15:00"
+			if end.Sub(start) > 24*time.Hour {
+				layout = "2006-01-02 15:00"
+			}
+			title = cinemaTime(performance.Time).Format(layout)
+		}
+		if len(groups) == 0 || groups[len(groups)-1].Title != title {
+			groups = append(groups, model.ScheduleGroup{Title: title})
+		}
+		last := &groups[len(groups)-1]
+		last.Performances = append(last.Performances, performance)
+	}
+	return c.JSON(http.StatusOK, groups)
+}
+
+// scheduleCalendar returns days with performances, two weeks from today by default
+func scheduleCalendar(c echo.Context) error {
+	start, end, err := scheduleRange(c, 14)
+	if err != nil {
+		return c.String(http.StatusBadRequest, `{"error": "wrong date"}`)
+	}
+	days := []model.ScheduleDay{}
+	err = db.Table("performances").
+		Select(`TO_CHAR((performances.time AT TIME ZONE ?) - ? * INTERVAL '1 minute', 'YYYY-MM-DD') AS date,
+			COUNT(performances.id) AS count,
+			MIN(performances.time) AS first,
+			MAX(performances.time) AS last,
+			SUM(performances.seats_free) AS seats_free,
+			SUM(performances.seats_total) AS seats_total`, cinemaZone(), dayStart().Minutes()).
+		Joins("JOIN movies ON movies.id = performances.movie_id").
+		Where("movies.is_active = ?", true).
+		Where("performances.is_active = ?", true).
+		Where("performances.time > ?", time.Now()).
+		Where("performances.time >= ? AND performances.time < ?", start, end).
+		Scopes(scheduleFilters(c)).
+		Group("date").
+		Order("date ASC").
+		Scan(&days).Error
+	if err != nil {
+		return c.String(http.StatusInternalServerError, `{"error": "internal server error"}`)
+	}
+	for i := range days {
+		days[i].NearlySoldOut = nearlySoldOut(days[i].SeatsFree, days[i].SeatsTotal)
+	}
+	return c.JSON(http.StatusOK, days)
+}
+
 // updateSchedule - updates schedule from extapi
 func updateSchedule() {
 	log.Println("Downloading schedule...")
 	var activePerformancesExternalIDs []int64
 	var activeMoviesExternalIDs []int64
 	activeMoviesIDsMap := make(map[int64]struct{})
-	for _, performance := range extapi.GetSchedule().Data {
+	externalSchedule, err := extapi.GetSchedule()
+	bookingAvailability(err)
+	for _, performance := range externalSchedule.Data {
 		if performance.CinemaID == appSettings.BookingSettings.CinemaID {
 			var tempPerformance model.Performance
-			tempPerformance.MovieID = getMovieID(performance.FilmId, performance.FullSizePoster)
+			movieID, film := getMovieID(performance.FilmId, performance.FullSizePoster)
+			tempPerformance.MovieID = movieID
 			db.Where("external_id = ?", performance.ID).FirstOrCreate(&tempPerformance)
+			tempPerformance.MovieID = movieID
+			tempPerformance.Variant = film.Variant
 			tempPerformance.ExternalID = performance.ID
 			tempPerformance.Price = performance.MinPrice
 			tempPerformance.HallName = performance.Hall
-			tempPerformance.Time, _ = time.Parse("2006-01-02 15:04:05", performance.Datetime)
-			if tempPerformance.Time.UTC().Before(time.Now().Add(time.Hour * time.Duration(appSettings.SiteSettings.TimeZoneOffset)).UTC()) {
+			tempPerformance.Time, _ = parseCinemaDate("2006-01-02 15:04:05", performance.Datetime)
+			if tempPerformance.Time.Before(time.Now()) {
 				tempPerformance.IsActive = false
 			} else {
 				tempPerformance.IsActive = true
@@ -72,7 +239,11 @@ func updateSchedule() {
 			if performance.ThreeD == "yes" {
 				tempPerformance.ThreeD = true
 			}
-			db.Save(&tempPerformance)
+			if !tempPerformance.AttributesLocked {
+				tempPerformance.PerformanceAttributes = performanceAttributes(tempPerformance, film)
+			}
+			// performance is already created by FirstOrCreate, seats are written by updateAvailability only
+			db.Omit("seats_free", "seats_total").Save(&tempPerformance)
 			if tempPerformance.IsActive {
 				activePerformancesExternalIDs = append(activePerformancesExternalIDs, tempPerformance.ExternalID)
 				// Add active movie to dummy map
@@ -90,8 +261,14 @@ func updateSchedule() {
 	}
 	if len(activeMoviesExternalIDs) > 0 {
 		db.Table("movies").Where("id IN (?)", activeMoviesExternalIDs).Updates(map[string]interface{}{"is_active": true})
-		db.Table("movies").Where("id NOT IN (?)", activeMoviesExternalIDs).Updates(map[string]interface{}{"is_active": false})
+		db.Table("movies").Where("id NOT IN (?) AND is_manual = ?", activeMoviesExternalIDs, false).Updates(map[string]interface{}{"is_active": false})
+	}
+	if err == nil {
+		importUpcoming()
 	}
+	updatePresale()
+	updateTagRules()
+	invalidateFeeds()
 }
 
 func updateScheduleHandler(c echo.Context) error {
@@ -99,55 +276,51 @@ func updateScheduleHandler(c echo.Context) error {
 	return c.String(http.StatusOK, `{"message":"success"}`)
 }
 
-func getMovieID(movieID int64, fullSizePoster string) int64 {
+// getMovieID returns id of movie which performances of booking film belong to and movie of the film
+func getMovieID(movieID int64, fullSizePoster string) (int64, model.Movie) {
 	var movie model.Movie
 	if err := db.Where("external_id = ?", movieID).First(&movie).Error; errors.Is(err, gorm.ErrRecordNotFound) {
-		tempMovie := extapi.GetMovie(movieID)
-		movie.Name = tempMovie.Data.Name
-		movie.NameSecondary = tempMovie.Data.NameSecondary
-		var kinopoisk model.MovieKinopoisk
-		kinopoisk, _ = utils.Kinopoisk(strings.Split(movie.NameSecondary, "предсеанс")[0], appSettings.SiteSettings.KinopoiskAPI)
-		movie.Age = tempMovie.Data.AgeLimit
-		movie.ExternalID = tempMovie.Data.ID
-		movie.Description = tempMovie.Data.AnnotationFull
-		if movie.Description == "" && len(kinopoisk.Films) > 0 {
-			movie.Description = kinopoisk.Films[0].Description
-		}
-		if movie.Country == "" && len(kinopoisk.Films) > 0 {
-			for _, country := range kinopoisk.Films[0].Countries {
-				movie.Country += " " + country.Country
-			}
-		}
-		movie.Genres = tempMovie.Data.Genre
-		movie.Duration, _ = strconv.ParseInt(tempMovie.Data.Duration, 10, 64)
-		movie.RentCertificate = tempMovie.Data.RentCertificate
-		if tempMovie.Data.Premiere != "" {
-			movie.Premiere, _ = time.Parse("2006-01-02", tempMovie.Data.Premiere)
-		}
-		if tempMovie.Data.PremiereDateRussia != "" {
-			movie.Premiere, _ = time.Parse("2006-01-02", tempMovie.Data.PremiereDateRussia)
-		}
-		if fullSizePoster != "" {
-			movie.Poster = utils.DownloadImage(fullSizePoster, 500)
-		}
-		if appSettings.SiteSettings.KinopoiskAPI != "" {
-			if movie.Poster == "" || movie.Backdrop == "" {
-				if movie.Poster == "" && len(kinopoisk.Films) > 0 {
-					movie.Poster = utils.DownloadImage(kinopoisk.Films[0].Poster, 500)
-				}
-				if movie.Backdrop == "" && len(kinopoisk.Films) > 0 {
-					if len(kinopoisk.Films[0].Images) > 0 {
-						movie.Backdrop = kinopoisk.Films[0].Images[0].FilePath
-					}
-				}
-			}
-		}
+		movie = createFilmMovie(extapi.GetMovie(movieID).Data, fullSizePoster)
+	}
+	film := movie
+	movie = followMerges(movie)
+	parent := variantParent(&movie)
+	parent.IsActive = true
+	db.Save(&parent)
+	return int64(parent.ID), film
+}
 
-		movie.Youtube = utils.MovieTrailer(movie.NameSecondary, appSettings.SiteSettings.YoutubeAPIKey)
-		movie.IsPushkin = (tempMovie.Data.PushkinCardEventId != "" && len(appSettings.BanksSettings) > 1)
-		db.Create(&movie)
+// createFilmMovie creates movie of booking system film with metadata and trailer
+func createFilmMovie(film extapi.Film, fullSizePoster string) model.Movie {
+	var movie model.Movie
+	movie.Name = film.Name
+	movie.NameSecondary = film.NameSecondary
+	movie.Age = film.AgeLimit
+	movie.ExternalID = film.ID
+	movie.Description = film.AnnotationFull
+	movie.Genres = film.Genre
+	movie.Duration, _ = strconv.ParseInt(film.Duration, 10, 64)
+	movie.RentCertificate = film.RentCertificate
+	if title := setMovieVariant(&movie); movie.Variant != "" && !hasVariantParent(movie.TitleKey) {
+		// the first variant is a parent, its card shows film title
+		movie.Name = title
+	}
+	if film.Premiere != "" {
+		movie.Premiere, _ = parseCinemaDate("2006-01-02", film.Premiere)
+	}
+	if film.PremiereDateRussia != "" {
+		movie.Premiere, _ = parseCinemaDate("2006-01-02", film.PremiereDateRussia)
+	}
+	if fullSizePoster != "" {
+		movie.Poster = downloadImage(fullSizePoster)
+	}
+	if candidate, err := metadataChain(false).Fetch(metadataQuery(movie)); err == nil {
+		applyMetadata(&movie, candidate, false)
 	}
-	movie.IsActive = true
-	db.Save(&movie)
-	return int64(movie.ID)
+	movie.IsPushkin = (film.PushkinCardEventId != "" && len(appSettings.BanksSettings) > 1)
+	movie.ShowInUpcoming = true
+	db.Create(&movie)
+	syncMovieGenres(&movie)
+	autoTrailer(movie)
+	return movie
 }
@@ -0,0 +1,111 @@
+package poravkino
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/eugenetolok/go-poravkino/pkg/model"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	searchLimit             = 20
+	suggestLimit            = 8
+	searchNextPerformances  = 3
+	searchSimilarityMinimum = 0.3
+)
+
+// movieSearchDocument must be the same as in movies_search_idx index
+const movieSearchDocument = `setweight(to_tsvector('russian', coalesce(name, '') || ' ' || coalesce(name_secondary, '')), 'A') || ` +
+	`setweight(to_tsvector('russian', coalesce(actors, '') || ' ' || coalesce(director, '')), 'B') || ` +
+	`setweight(to_tsvector('russian', coalesce(genres, '')), 'C')`
+
+// migrateSearch creates full-text and trigram indexes of movies
+func migrateSearch() {
+	for _, sql := range []string{
+		`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+		`CREATE INDEX IF NOT EXISTS movies_search_idx ON movies USING GIN ((` + movieSearchDocument + `))`,
+		`CREATE INDEX IF NOT EXISTS movies_name_trgm_idx ON movies USING GIN (name gin_trgm_ops)`,
+		`CREATE INDEX IF NOT EXISTS movies_name_secondary_trgm_idx ON movies USING GIN (name_secondary gin_trgm_ops)`,
+	} {
+		if err := db.Exec(sql).Error; err != nil {
+			log.Println("search migration error:", err)
+		}
+	}
+}
+
+// searchQuery makes prefix tsquery from words of user input, e.g. "дюна час" -> "дюна:* & час:*"
+func searchQuery(q string) string {
+	words := strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = word + ":*"
+	}
+	return strings.Join(words, " & ")
+}
+
+// searchMovies finds active movies by full-text and trigram similarity
+func searchMovies(q string, limit int) []model.Movie {
+	movies := []model.Movie{}
+	tsquery := searchQuery(q)
+	if tsquery == "" {
+		return movies
+	}
+	db.Where("movies.is_active = ? AND movies.merged_into_id = 0 AND movies.parent_id = 0", true).
+		Where("("+movieSearchDocument+") @@ to_tsquery('russian', ?) OR word_similarity(?, movies.name) > ? OR word_similarity(?, movies.name_secondary) > ?",
+			tsquery, q, searchSimilarityMinimum, q, searchSimilarityMinimum).
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "ts_rank(" + movieSearchDocument + ", to_tsquery('russian', ?)) + GREATEST(word_similarity(?, movies.name), word_similarity(?, movies.name_secondary)) DESC",
+			Vars: []interface{}{tsquery, q, q},
+		}}).
+		Limit(limit).
+		Preload("Performances", func(db *gorm.DB) *gorm.DB {
+			return db.Where("is_active = ? AND time > ?", true, cinemaNow()).Order("time ASC")
+		}).
+		Find(&movies)
+	for i := range movies {
+		if len(movies[i].Performances) > searchNextPerformances {
+			movies[i].Performances = movies[i].Performances[:searchNextPerformances]
+		}
+	}
+	return movies
+}
+
+// search returns movies with their next performances, GET /api/search?q=
+func search(c echo.Context) error {
+	return c.JSON(http.StatusOK, searchMovies(c.QueryParam("q"), searchLimit))
+}
+
+// MovieSuggestion - autocomplete item
+type MovieSuggestion struct {
+	ID            uint   `json:"id"`
+	Name          string `json:"name"`
+	NameSecondary string `json:"name_secondary"`
+	Poster        string `json:"poster"`
+}
+
+// searchSuggest returns movie names for autocomplete, GET /api/search/suggest?q=
+func searchSuggest(c echo.Context) error {
+	suggestions := []MovieSuggestion{}
+	q := strings.TrimSpace(c.QueryParam("q"))
+	if q == "" {
+		return c.JSON(http.StatusOK, suggestions)
+	}
+	prefix := strings.NewReplacer("%", `\%`, "_", `\_`).Replace(q) + "%"
+	db.Model(&model.Movie{}).
+		Select("id", "name", "name_secondary", "poster").
+		Where("is_active = ? AND merged_into_id = 0 AND parent_id = 0", true).
+		Where("name ILIKE ? OR name_secondary ILIKE ? OR word_similarity(?, name) > ?", prefix, prefix, q, searchSimilarityMinimum).
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "(name ILIKE ?) DESC, word_similarity(?, name) DESC",
+			Vars: []interface{}{prefix, q},
+		}}).
+		Limit(suggestLimit).
+		Find(&suggestions)
+	return c.JSON(http.StatusOK, suggestions)
+}
@@ -0,0 +1,366 @@
+package poravkino
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"html/template"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/eugenetolok/go-poravkino/pkg/model"
+	"github.com/eugenetolok/go-poravkino/pkg/utils"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+const descriptionLimit = 200
+
+var (
+	// indexTemplate is dist/index.html, it is rendered per request and never overwritten
+	indexTemplate *template.Template
+
+	titleTag  = regexp.MustCompile(`(?is)<title>.*?</title>`)
+	metaTags  = regexp.MustCompile(`(?is)<meta[^>]+(name="description"|property="og:[^"]*")[^>]*>\s*`)
+	htmlTags  = regexp.MustCompile(`(?s)<[^>]*>`)
+	spaceRuns = regexp.MustCompile(`\s+`)
+)
+
+// pageMeta - title, link preview and structured data of SPA route
+type pageMeta struct {
+	Title       string
+	Description string
+	Image       string
+	URL         string
+	Type        string // og:type
+	JSONLD      []interface{}
+}
+
+// initHTML parses SPA index as template of cinema settings
+func initHTML() {
+	t, err := template.ParseFiles(utils.WorkDir() + "/dist/index.html")
+	if err != nil {
+		log.Print("index template parsing error: ", err)
+		return
+	}
+	indexTemplate = t
+}
+
+// plainText strips html and cuts text for meta description
+func plainText(s string) string {
+	s = strings.TrimSpace(spaceRuns.ReplaceAllString(html.UnescapeString(htmlTags.ReplaceAllString(s, " ")), " "))
+	if utf8.RuneCountInString(s) <= descriptionLimit {
+		return s
+	}
+	runes := []rune(s)
+	return strings.TrimSpace(string(runes[:descriptionLimit-1])) + "…"
+}
+
+// defaultMeta describes the cinema itself
+func defaultMeta(path string) pageMeta {
+	cinema := appSettings.CinemaSettings
+	title := cinema.SiteName
+	if title == "" {
+		title = cinema.CinemaName
+	}
+	return pageMeta{
+		Title:       title,
+		Description: plainText(strings.Join(nonEmpty(cinema.CinemaName, cinema.CityName, cinema.Address), ", ")),
+		Image:       siteURL(cinema.Logo),
+		URL:         siteURL(path),
+		Type:        "website",
+		JSONLD:      []interface{}{theaterLD()},
+	}
+}
+
+func nonEmpty(values ...string) []string {
+	var result []string
+	for _, value := range values {
+		if value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// theaterLD is schema.org MovieTheater of cinema
+func theaterLD() map[string]interface{} {
+	cinema := appSettings.CinemaSettings
+	return map[string]interface{}{
+		"@context": "https://schema.org",
+		"@type":    "MovieTheater",
+		"name":     cinema.CinemaName,
+		"url":      siteURL("/"),
+		"address": map[string]interface{}{
+			"@type":           "PostalAddress",
+			"addressLocality": cinema.CityName,
+			"streetAddress":   cinema.Address,
+		},
+	}
+}
+
+// movieLD is schema.org Movie
+func movieLD(movie model.Movie) map[string]interface{} {
+	ld := map[string]interface{}{
+		"@context":    "https://schema.org",
+		"@type":       "Movie",
+		"name":        movie.Name,
+		"description": plainText(movie.Description),
+		"image":       siteURL(movie.Poster),
+		"url":         siteURL(fmt.Sprintf("/movie/%d", movie.ID)),
+	}
+	if movie.Duration > 0 {
+		ld["duration"] = fmt.Sprintf("PT%dM", movie.Duration)
+	}
+	if movie.Age > 0 {
+		ld["contentRating"] = fmt.Sprintf("%d+", movie.Age)
+	}
+	if len(movie.GenreList) > 0 {
+		var genres []string
+		for _, genre := range movie.GenreList {
+			genres = append(genres, genre.Name)
+		}
+		ld["genre"] = genres
+	}
+	if !movie.Premiere.IsZero() {
+		ld["datePublished"] = cinemaTime(movie.Premiere).Format("2006-01-02")
+	}
+	return ld
+}
+
+// screeningLD is schema.org ScreeningEvent of performance
+func screeningLD(performance model.Performance, movie model.Movie) map[string]interface{} {
+	url := siteURL(fmt.Sprintf("/performance/%d", performance.ID))
+	availability := "https://schema.org/InStock"
+	if performance.SeatsTotal > 0 && performance.SeatsFree == 0 {
+		availability = "https://schema.org/SoldOut"
+	} else if nearlySoldOut(performance.SeatsFree, performance.SeatsTotal) {
+		availability = "https://schema.org/LimitedAvailability"
+	}
+	start := cinemaTime(performance.Time)
+	ld := map[string]interface{}{
+		"@context":  "https://schema.org",
+		"@type":     "ScreeningEvent",
+		"name":      movie.Name,
+		"startDate": start.Format(time.RFC3339),
+		"url":       url,
+		"location":  theaterLD(),
+		"workPresented": map[string]interface{}{
+			"@type": "Movie",
+			"name":  movie.Name,
+			"url":   siteURL(fmt.Sprintf("/movie/%d", movie.ID)),
+		},
+		"offers": map[string]interface{}{
+			"@type":         "Offer",
+			"price":         performance.Price,
+			"priceCurrency": "RUB",
+			"url":           url,
+			"availability":  availability,
+		},
+	}
+	if duration := movie.Duration + movie.AddDuration; duration > 0 {
+		ld["endDate"] = start.Add(time.Minute * time.Duration(duration)).Format(time.RFC3339)
+	}
+	if performance.Format != "" {
+		ld["videoFormat"] = performance.Format
+	}
+	return ld
+}
+
+// movieMeta describes movie with its nearest performances,
+// merged duplicates and variants are described by the movie getMovie shows
+func movieMeta(id string) (pageMeta, bool) {
+	var movie model.Movie
+	if err := db.First(&movie, id).Error; err != nil {
+		return pageMeta{}, false
+	}
+	movie = followMerges(movie)
+	movieID := movie.ID
+	if movie.ParentID != 0 {
+		movieID = movie.ParentID
+	}
+	movie = model.Movie{}
+	err := db.Preload("Performances", func(db *gorm.DB) *gorm.DB {
+		return db.Where("is_active = ? AND time > ?", true, time.Now()).Order("time ASC").Limit(20)
+	}).Preload("GenreList").First(&movie, movieID).Error
+	if err != nil {
+		return pageMeta{}, false
+	}
+	meta := pageMeta{
+		Title:       movie.Name + " — " + defaultMeta("").Title,
+		Description: plainText(movie.Description),
+		Image:       siteURL(movie.Poster),
+		URL:         siteURL(fmt.Sprintf("/movie/%d", movie.ID)),
+		Type:        "video.movie",
+		JSONLD:      []interface{}{movieLD(movie)},
+	}
+	for _, performance := range movie.Performances {
+		meta.JSONLD = append(meta.JSONLD, screeningLD(performance, movie))
+	}
+	return meta, true
+}
+
+// performanceMeta describes performance
+func performanceMeta(id string) (pageMeta, bool) {
+	var performance model.Performance
+	if err := db.Preload("Movie").First(&performance, id).Error; err != nil {
+		return pageMeta{}, false
+	}
+	start := cinemaTime(performance.Time)
+	return pageMeta{
+		Title:       fmt.Sprintf("%s, %s — %s", performance.Movie.Name, start.Format("02.01 15:04"), defaultMeta("").Title),
+		Description: plainText(performance.HallName + ". " + performance.Movie.Description),
+		Image:       siteURL(performance.Movie.Poster),
+		URL:         siteURL(fmt.Sprintf("/performance/%d", performance.ID)),
+		Type:        "website",
+		JSONLD:      []interface{}{screeningLD(performance, performance.Movie)},
+	}, true
+}
+
+// sitePageMeta describes CMS page with its SEO fields
+func sitePageMeta(slug string) (pageMeta, bool) {
+	var page model.Page
+	if err := db.Where("slug = ? AND is_published = ? AND link_url = ''", slug, true).First(&page).Error; err != nil {
+		return pageMeta{}, false
+	}
+	meta := defaultMeta("/page/" + page.Slug)
+	meta.Title = page.Title + " — " + meta.Title
+	if page.SEOTitle != "" {
+		meta.Title = page.SEOTitle
+	}
+	renderPage(&page)
+	meta.Description = plainText(page.HTML)
+	if page.SEODescription != "" {
+		meta.Description = page.SEODescription
+	}
+	if page.SEOImage != "" {
+		meta.Image = siteURL(page.SEOImage)
+	}
+	return meta, true
+}
+
+// renderIndex renders index with cinema settings and replaces its meta tags
+func renderIndex(meta pageMeta) ([]byte, error) {
+	if indexTemplate == nil {
+		return nil, errors.New("no index template")
+	}
+	buf := new(bytes.Buffer)
+	if err := indexTemplate.Execute(buf, appSettings.CinemaSettings); err != nil {
+		return nil, err
+	}
+	page := metaTags.ReplaceAllString(buf.String(), "")
+	page = titleTag.ReplaceAllLiteralString(page, "<title>"+html.EscapeString(meta.Title)+"</title>")
+
+	var head strings.Builder
+	tag := func(attr, name, content string) {
+		if content != "" {
+			fmt.Fprintf(&head, "<meta %s=\"%s\" content=\"%s\" />\n", attr, name, html.EscapeString(content))
+		}
+	}
+	tag("name", "description", meta.Description)
+	tag("property", "og:title", meta.Title)
+	tag("property", "og:description", meta.Description)
+	tag("property", "og:image", meta.Image)
+	tag("property", "og:url", meta.URL)
+	tag("property", "og:type", meta.Type)
+	tag("property", "og:site_name", appSettings.CinemaSettings.CinemaName)
+	if meta.URL != "" {
+		fmt.Fprintf(&head, "<link rel=\"canonical\" href=\"%s\" />\n", html.EscapeString(meta.URL))
+	}
+	for _, ld := range meta.JSONLD {
+		data, err := json.Marshal(ld)
+		if err != nil {
+			return nil, err
+		}
+		// json.Marshal escapes <, > and &, so data can't close the script
+		fmt.Fprintf(&head, "<script type=\"application/ld+json\">%s</script>\n", data)
+	}
+	page = strings.Replace(page, "</head>", head.String()+"</head>", 1)
+	return []byte(page), nil
+}
+
+// spaIndex serves SPA index with meta of route, unknown movies and pages get 404 status
+func spaIndex(c echo.Context) error {
+	path := c.Request().URL.Path
+	if path == "/index.html" {
+		path = "/"
+	}
+	if strings.HasPrefix(path, "/api/") {
+		return c.String(http.StatusNotFound, `{"error": "not found"}`)
+	}
+	if method := c.Request().Method; method != http.MethodGet && method != http.MethodHead {
+		return c.String(http.StatusMethodNotAllowed, `{"error": "method not allowed"}`)
+	}
+	if strings.Contains(path[strings.LastIndex(path, "/")+1:], ".") {
+		return c.String(http.StatusNotFound, "not found")
+	}
+	status := http.StatusOK
+	meta := defaultMeta(path)
+	var found bool
+	switch {
+	case strings.HasPrefix(path, "/movie/"):
+		meta, found = movieMeta(c.Param("id"))
+	case strings.HasPrefix(path, "/performance/"):
+		meta, found = performanceMeta(c.Param("id"))
+	case strings.HasPrefix(path, "/page/"):
+		meta, found = sitePageMeta(c.Param("slug"))
+	default:
+		found = true
+	}
+	if !found {
+		status = http.StatusNotFound
+		meta = defaultMeta(path)
+	}
+	page, err := renderIndex(meta)
+	if err != nil {
+		log.Print("index template executing error: ", err)
+		return c.String(http.StatusInternalServerError, "index error")
+	}
+	return c.HTMLBlob(status, page)
+}
+
+// sitemap lists public routes, active movies and published pages
+func sitemap(c echo.Context) error {
+	type url struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod,omitempty"`
+	}
+	urlset := struct {
+		XMLName xml.Name `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+		URLs    []url    `xml:"url"`
+	}{}
+	for _, path := range []string{"/", "/soon", "/faq", "/contacts"} {
+		urlset.URLs = append(urlset.URLs, url{Loc: siteURL(path)})
+	}
+	var movies []model.Movie
+	db.Select("id", "updated_at").
+		Where("merged_into_id = 0 AND parent_id = 0").
+		Where("is_active = ? OR (show_in_upcoming = ? AND premiere >= ?)", true, true, cinemaToday()).
+		Order("id ASC").
+		Find(&movies)
+	for _, movie := range movies {
+		urlset.URLs = append(urlset.URLs, url{Loc: siteURL(fmt.Sprintf("/movie/%d", movie.ID)), LastMod: movie.UpdatedAt.Format("2006-01-02")})
+	}
+	var pages []model.Page
+	db.Select("slug", "updated_at").Where("is_published = ? AND link_url = ''", true).Order("id ASC").Find(&pages)
+	for _, page := range pages {
+		urlset.URLs = append(urlset.URLs, url{Loc: siteURL("/page/" + page.Slug), LastMod: page.UpdatedAt.Format("2006-01-02")})
+	}
+	data, err := xml.MarshalIndent(urlset, "", "  ")
+	if err != nil {
+		return err
+	}
+	return c.Blob(http.StatusOK, "application/xml; charset=utf-8", append([]byte(xml.Header), data...))
+}
+
+// robots allows everything except api and tickets, images are allowed for previews
+func robots(c echo.Context) error {
+	return c.String(http.StatusOK, "User-agent: *\nAllow: /api/images/\nDisallow: /api/\nDisallow: /my-tickets\nDisallow: /tickets\n\nSitemap: "+siteURL("/sitemap.xml")+"\n")
+}
@@ -0,0 +1,166 @@
+package poravkino
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"text/template"
+	"time"
+
+	"github.com/eugenetolok/go-poravkino/pkg/model"
+	"github.com/eugenetolok/go-poravkino/pkg/sms"
+)
+
+const (
+	smsKindTickets = "tickets"
+	smsKindRefund  = "refund"
+	smsMaxAttempts = 3
+
+	defaultTicketsSMS = "Билеты {{ .ExternalID }}-{{ .Secret }}: {{ .URL }}"
+	defaultRefundSMS  = "Возврат по заказу {{ .ExternalID }}-{{ .Secret }} оформлен, {{ .Amount }} руб."
+)
+
+// smsData is sale with links available in sms templates
+type smsData struct {
+	model.Sale
+	Domain string
+	URL    string
+}
+
+var errSMSRateLimit = errors.New("sms rate limit exceeded")
+
+// sendTicketsSMS sends ticket links for recently approved sales
+func sendTicketsSMS() {
+	if appSettings.SMSSettings.Provider == "" {
+		return
+	}
+	var sales []model.Sale
+	db.Where("bank_order_status = ? AND sms_sent = ? AND refund = ? AND phone <> '' AND created_at > ?", 2, false, false, time.Now().Add(-15*time.Minute)).Find(&sales)
+	for _, sale := range sales {
+		// failed sends are retried on next runs, until attempts are exhausted
+		err := sendSaleSMS(sale, smsKindTickets)
+		if err == nil || smsAttempts(sale.ID, smsKindTickets) >= smsMaxAttempts {
+			db.Model(&sale).Update("sms_sent", true)
+		}
+	}
+}
+
+// smsAttempts counts stored messages of kind for sale
+func smsAttempts(saleID uint, kind string) int64 {
+	var count int64
+	db.Model(&model.SMSMessage{}).Where("sale_id = ? AND kind = ?", saleID, kind).Count(&count)
+	return count
+}
+
+// sendRefundSMS confirms refund to buyer
+func sendRefundSMS(sale model.Sale) {
+	if appSettings.SMSSettings.Provider == "" || sale.Phone == "" {
+		return
+	}
+	sendSaleSMS(sale, smsKindRefund)
+}
+
+// sendSaleSMS renders template of kind, sends it and stores message with its status
+func sendSaleSMS(sale model.Sale, kind string) error {
+	message := model.SMSMessage{
+		SaleID: sale.ID,
+		Phone:  normalizePhone(sale.Phone),
+		Kind:   kind,
+		Status: sms.StatusFailed,
+	}
+	text, err := smsText(sale, kind)
+	if err == nil {
+		message.Text = text
+		err = smsRateLimit(message.Phone)
+	}
+	if err == nil {
+		var provider sms.Provider
+		provider, err = sms.NewProvider(appSettings.SMSSettings)
+		if err == nil {
+			message.ProviderID, err = provider.Send(message.Phone, message.Text)
+		}
+	}
+	if err != nil {
+		message.Error = err.Error()
+		log.Printf("sms %s for sale %s is not sent: %s", kind, sale.Secret, err)
+	} else {
+		message.Status = sms.StatusSent
+		log.Printf("sms %s for sale %s is sent", kind, sale.Secret)
+	}
+	db.Create(&message)
+	return err
+}
+
+func smsText(sale model.Sale, kind string) (string, error) {
+	source := appSettings.SMSSettings.TicketsTemplate
+	if source == "" {
+		source = defaultTicketsSMS
+	}
+	if kind == smsKindRefund {
+		source = appSettings.SMSSettings.RefundTemplate
+		if source == "" {
+			source = defaultRefundSMS
+		}
+	}
+	t, err := template.New(kind).Parse(source)
+	if err != nil {
+		return "", err
+	}
+	buf := new(bytes.Buffer)
+	err = t.Execute(buf, smsData{
+		Sale:   sale,
+		Domain: appSettings.CinemaSettings.DomainName,
+		URL:    fmt.Sprintf("https://%s/api/sales/code/lost?secret=%s", appSettings.CinemaSettings.DomainName, sale.Secret),
+	})
+	return buf.String(), err
+}
+
+// smsRateLimit checks how many messages were sent to phone during last hour
+func smsRateLimit(phone string) error {
+	limit := appSettings.SMSSettings.MaxPerHour
+	if limit <= 0 {
+		return nil
+	}
+	var count int64
+	db.Model(&model.SMSMessage{}).Where("phone = ? AND status <> ? AND created_at > ?", phone, sms.StatusFailed, time.Now().Add(-time.Hour)).Count(&count)
+	if count >= limit {
+		return errSMSRateLimit
+	}
+	return nil
+}
+
+// updateSMSStatuses asks provider about delivery of recent messages
+func updateSMSStatuses() {
+	if appSettings.SMSSettings.Provider == "" {
+		return
+	}
+	provider, err := sms.NewProvider(appSettings.SMSSettings)
+	if err != nil {
+		log.Println("sms provider error:", err)
+		return
+	}
+	var messages []model.SMSMessage
+	db.Where("status IN (?) AND provider_id <> '' AND created_at > ?", []string{sms.StatusQueued, sms.StatusSent}, time.Now().Add(-24*time.Hour)).Find(&messages)
+	for _, message := range messages {
+		status, err := provider.Status(message.ProviderID)
+		if err != nil {
+			log.Println("sms status error:", err)
+			continue
+		}
+		if status != message.Status {
+			db.Model(&message).Update("status", status)
+		}
+	}
+}
+
+// normalizePhone converts russian numbers to 7XXXXXXXXXX
+func normalizePhone(phone string) string {
+	if len(phone) == 11 && phone[0] == '8' {
+		return "7" + phone[1:]
+	}
+	if len(phone) == 10 {
+		return "7" + phone
+	}
+	return phone
+}
@@ -0,0 +1,103 @@
+package poravkino
+
+import (
+	"io/fs"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/eugenetolok/go-poravkino/pkg/model"
+	"github.com/eugenetolok/go-poravkino/pkg/storage"
+	"github.com/eugenetolok/go-poravkino/pkg/utils"
+	"github.com/labstack/echo/v4"
+)
+
+// legacyUploadsDir - uploads were written into dist before storage settings
+const legacyUploadsDir = "dist/uploads"
+
+// uploadStorage returns storage from settings, local directory on wrong settings
+func uploadStorage() storage.Storage {
+	s, err := storage.New(appSettings.StorageSettings, utils.WorkDir())
+	if err != nil {
+		log.Println("storage settings error:", err)
+		s, _ = storage.New(model.StorageSettings{}, utils.WorkDir())
+	}
+	return s
+}
+
+// storageKey returns key of object by its url in storage s
+func storageKey(s storage.Storage, url string) (string, bool) {
+	base := s.URL("")
+	if !strings.HasPrefix(url, base) {
+		return "", false
+	}
+	key, err := storage.CleanKey(strings.TrimPrefix(url, base))
+	return key, err == nil && key != ""
+}
+
+// getUpload serves /uploads/* which are not in dist, remote objects are redirected
+func getUpload(c echo.Context) error {
+	key, err := storage.CleanKey(c.Param("*"))
+	if err != nil {
+		return c.String(http.StatusNotFound, `{"error": "no such file"}`)
+	}
+	s := uploadStorage()
+	if _, ok := s.(*storage.Local); !ok {
+		return c.Redirect(http.StatusMovedPermanently, s.URL(key))
+	}
+	f, err := s.Get(key)
+	if err == storage.ErrNotFound {
+		return c.String(http.StatusNotFound, `{"error": "no such file"}`)
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = echo.MIMEOctetStream
+	}
+	return c.Stream(http.StatusOK, contentType, f)
+}
+
+// migrateUploads moves files from dist/uploads to configured storage keeping their keys
+func migrateUploads() {
+	s := uploadStorage()
+	dir := workPath(legacyUploadsDir)
+	if local, ok := s.(*storage.Local); ok && filepath.Clean(local.Dir) == filepath.Clean(dir) {
+		log.Println("storage directory is " + legacyUploadsDir + ", nothing to move")
+		return
+	}
+	var moved, failed int
+	filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		f, err := os.Open(name)
+		if err != nil {
+			log.Println("upload migration error:", key, err)
+			failed++
+			return nil
+		}
+		err = s.Put(key, f, mime.TypeByExtension(path.Ext(key)))
+		f.Close()
+		if err != nil {
+			log.Println("upload migration error:", key, err)
+			failed++
+			return nil
+		}
+		os.Remove(name)
+		moved++
+		return nil
+	})
+	log.Printf("%d uploads are moved to storage, %d failed", moved, failed)
+}
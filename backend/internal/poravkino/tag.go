@@ -0,0 +1,162 @@
+package poravkino
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/eugenetolok/go-poravkino/pkg/model"
+	"github.com/eugenetolok/go-poravkino/pkg/utils"
+	"github.com/jinzhu/copier"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// Tag rules
+const (
+	tagRuleMaxAge     = "max_age"     // age limit is not greater than value
+	tagRulePushkin    = "pushkin"     // Pushkin card
+	tagRuleLastChance = "last_chance" // last performance within value days
+	tagRuleNew        = "new"         // premiere within last value days
+	tagRuleGenre      = "genre"       // genre slug
+)
+
+// tagRuleQuery returns ids query of movies matching rule, nil for manual tags
+func tagRuleQuery(tag model.Tag) (*gorm.DB, error) {
+	query := db.Model(&model.Movie{}).Select("movies.id").
+		Where("movies.is_active = ? AND movies.merged_into_id = 0 AND movies.parent_id = 0", true)
+	switch tag.Rule {
+	case "":
+		return nil, nil
+	case tagRulePushkin:
+		return query.Where("movies.is_pushkin = ?", true), nil
+	case tagRuleGenre:
+		return query.Where("movies.id IN (SELECT movie_genres.movie_id FROM movie_genres JOIN genres ON genres.id = movie_genres.genre_id WHERE genres.slug = ?)", tag.RuleValue), nil
+	}
+	value, err := strconv.ParseInt(tag.RuleValue, 10, 64)
+	if err != nil {
+		return nil, errors.New("rule value must be a number")
+	}
+	switch tag.Rule {
+	case tagRuleMaxAge:
+		return query.Where("movies.age <= ?", value), nil
+	case tagRuleLastChance:
+		return query.Where("movies.id IN (SELECT movie_id FROM performances WHERE is_active = ? GROUP BY movie_id HAVING MAX(time) <= ?)",
+			true, cinemaNow().AddDate(0, 0, int(value))), nil
+	case tagRuleNew:
+		today := cinemaToday()
+		// premiere of tomorrow is not new yet
+		return query.Where("movies.premiere >= ? AND movies.premiere < ?", today.AddDate(0, 0, -int(value)), today.AddDate(0, 0, 1)), nil
+	}
+	return nil, errors.New("unknown tag rule")
+}
+
+// updateTagRules links movies to rule-based tags
+func updateTagRules() {
+	var tags []model.Tag
+	db.Where("rule <> ''").Find(&tags)
+	for _, tag := range tags {
+		query, err := tagRuleQuery(tag)
+		if err != nil {
+			log.Println("tag rule error:", tag.Slug, err)
+			continue
+		}
+		movies := []model.Movie{}
+		db.Where("id IN (?)", query).Find(&movies)
+		if err := db.Model(&tag).Association("Movies").Replace(movies); err != nil {
+			log.Println("tag rule error:", tag.Slug, err)
+		}
+	}
+}
+
+// getTags returns all tags
+func getTags(c echo.Context) error {
+	tags := []model.Tag{}
+	db.Order("name ASC").Find(&tags)
+	return c.JSON(http.StatusOK, tags)
+}
+
+// bindTag binds and checks tag, slug is made from name if empty
+func bindTag(c echo.Context, tag *model.Tag) error {
+	var tagIn model.TagIn
+	if err := c.Bind(&tagIn); err != nil || tagIn.Name == "" {
+		return errors.New("bad request")
+	}
+	copier.Copy(tag, &tagIn)
+	if tag.Slug == "" {
+		tag.Slug = utils.Slug(tag.Name)
+	}
+	_, err := tagRuleQuery(*tag)
+	return err
+}
+
+// createTag creates tag, rule-based tags are filled at once
+func createTag(c echo.Context) error {
+	var tag model.Tag
+	if err := bindTag(c, &tag); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	if err := db.Create(&tag).Error; err != nil {
+		return c.String(http.StatusConflict, `{"error": "tag already exists"}`)
+	}
+	updateTagRules()
+	return c.JSON(http.StatusCreated, tag)
+}
+
+// updateTag updates tag
+func updateTag(c echo.Context) error {
+	var tag model.Tag
+	if err := db.First(&tag, c.Param("id")).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.String(http.StatusNotFound, `{"error": "no such tag"}`)
+	}
+	if err := bindTag(c, &tag); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+	if err := db.Save(&tag).Error; err != nil {
+		return c.String(http.StatusConflict, `{"error": "tag already exists"}`)
+	}
+	updateTagRules()
+	return c.JSON(http.StatusOK, tag)
+}
+
+// deleteTag deletes tag and its links
+func deleteTag(c echo.Context) error {
+	var tag model.Tag
+	if err := db.First(&tag, c.Param("id")).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.String(http.StatusNotFound, `{"error": "no such tag"}`)
+	}
+	db.Model(&tag).Association("Movies").Clear()
+	db.Model(&model.Collection{}).Where("tag_id = ?", tag.ID).Update("tag_id", 0)
+	db.Delete(&tag)
+	return c.String(http.StatusOK, `{"success":"tag has been deleted"}`)
+}
+
+// setMovieTags replaces manual tags of movie, rule-based tags stay
+func setMovieTags(c echo.Context) error {
+	var movie model.Movie
+	if err := db.Preload("Tags").First(&movie, c.Param("id")).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.String(http.StatusNotFound, `{"error": "no such movie"}`)
+	}
+	var ids model.IDs
+	if err := c.Bind(&ids); err != nil {
+		return c.String(http.StatusBadRequest, `{"error": "bad request"}`)
+	}
+	tags := []model.Tag{}
+	if len(ids.IDs) > 0 {
+		db.Where("id IN (?) AND rule = ''", ids.IDs).Find(&tags)
+	}
+	if len(tags) != len(ids.IDs) {
+		return c.String(http.StatusBadRequest, `{"error": "wrong manual tag ids"}`)
+	}
+	for _, tag := range movie.Tags {
+		if tag.Rule != "" {
+			tags = append(tags, tag)
+		}
+	}
+	if err := db.Model(&movie).Association("Tags").Replace(tags); err != nil {
+		return c.String(http.StatusInternalServerError, `{"error": "tags link error"}`)
+	}
+	movie.Tags = tags
+	return c.JSON(http.StatusOK, movie)
+}
@@ -0,0 +1,174 @@
+package poravkino
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eugenetolok/go-poravkino/pkg/model"
+	"github.com/eugenetolok/go-poravkino/pkg/telegram"
+	"gorm.io/gorm"
+)
+
+// bookingDown keeps booking system state to alert only on changes
+var (
+	bookingDown     bool
+	bookingDownLock sync.Mutex
+)
+
+func telegramBot() *telegram.Bot {
+	if appSettings.BotSettings.TelegramBotAPI == "" {
+		return nil
+	}
+	return telegram.NewBot(appSettings.BotSettings.TelegramBotAPI, appSettings.BotSettings.APIURL)
+}
+
+// alert sends message to all allowed chats
+func alert(text string) {
+	bot := telegramBot()
+	if bot == nil {
+		return
+	}
+	for _, chatID := range appSettings.BotSettings.AllowedChats {
+		if err := bot.SendMessage(chatID, text); err != nil {
+			log.Println("telegram alert error:", err)
+		}
+	}
+}
+
+// bookingAvailability alerts when booking system goes down and when it is back
+func bookingAvailability(err error) {
+	// state is changed under lock, messages are sent after it
+	var text string
+	bookingDownLock.Lock()
+	if err != nil && !bookingDown {
+		bookingDown = true
+		// url errors carry request url with booking api key, only the cause is sent
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		text = "Система бронирования недоступна: " + err.Error()
+	}
+	if err == nil && bookingDown {
+		bookingDown = false
+		text = "Система бронирования снова доступна"
+	}
+	bookingDownLock.Unlock()
+	if text != "" {
+		alert(text)
+	}
+}
+
+// dailyReport sends today sales totals
+func dailyReport() {
+	alert(todayTotals())
+}
+
+func todayTotals() string {
+	dayStart := cinemaToday()
+	var totals struct {
+		Sales   int64
+		Tickets int64
+		Amount  int64
+	}
+	var refunds int64
+	db.Model(&model.Sale{}).
+		Select("COUNT(*) AS sales, COALESCE(SUM(jsonb_array_length(tickets)), 0) AS tickets, COALESCE(SUM(amount), 0) AS amount").
+		Where("bank_order_status = ? AND refund = ? AND created_at >= ?", 2, false, dayStart).
+		Scan(&totals)
+	db.Model(&model.Sale{}).Where("refund = ? AND updated_at >= ?", true, dayStart).Count(&refunds)
+	return fmt.Sprintf("Продажи за %s\nЗаказов: %d\nБилетов: %d\nСумма: %d руб.\nВозвратов: %d",
+		dayStart.Format("02.01.2006"), totals.Sales, totals.Tickets, totals.Amount, refunds)
+}
+
+// runTelegramBot polls bot updates and answers admin commands from allowed chats
+func runTelegramBot() {
+	var offset int64
+	for {
+		bot := telegramBot()
+		if bot == nil {
+			time.Sleep(time.Minute)
+			continue
+		}
+		updates, err := bot.GetUpdates(offset, 30)
+		if err != nil {
+			log.Println("telegram updates error:", err)
+			time.Sleep(10 * time.Second)
+			continue
+		}
+		for _, update := range updates {
+			offset = update.UpdateID + 1
+			if update.Message == nil || !allowedChat(update.Message.Chat.ID) {
+				continue
+			}
+			if err := bot.SendMessage(update.Message.Chat.ID, botCommand(update.Message.Text)); err != nil {
+				log.Println("telegram answer error:", err)
+			}
+		}
+	}
+}
+
+func allowedChat(chatID int64) bool {
+	for _, allowed := range appSettings.BotSettings.AllowedChats {
+		if allowed == chatID {
+			return true
+		}
+	}
+	return false
+}
+
+const botHelp = `/sale <код> - информация о продаже
+/refund <номер> - возврат продажи по номеру в системе бронирования
+/today - продажи за сегодня`
+
+// botCommand executes command and returns answer
+func botCommand(text string) string {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return botHelp
+	}
+	// commands in groups look like /today@botname
+	command := strings.Split(fields[0], "@")[0]
+	switch {
+	case command == "/today":
+		return todayTotals()
+	case command == "/sale" && len(fields) == 2:
+		var sale model.Sale
+		if err := db.Preload("Performance.Movie").Where("secret = ?", fields[1]).First(&sale).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+			return "Продажа не найдена"
+		}
+		return saleSummary(sale)
+	case command == "/refund" && len(fields) == 2:
+		var sale model.Sale
+		if err := db.Preload("Performance.Movie").Where("external_id = ?", fields[1]).First(&sale).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+			return "Продажа не найдена"
+		}
+		if sale.Refund {
+			return "Возврат уже был выполнен"
+		}
+		if err := refundSale(&sale); err != nil {
+			return "Ошибка возврата: " + err.Error()
+		}
+		log.Println("Telegram refund of sale:", sale.ID)
+		return "Возврат выполнен\n" + saleSummary(sale)
+	}
+	return botHelp
+}
+
+func saleSummary(sale model.Sale) string {
+	var seats []string
+	for _, ticket := range sale.Tickets {
+		seats = append(seats, fmt.Sprintf("ряд %s место %s", ticket.Row, ticket.Seat))
+	}
+	return fmt.Sprintf("Продажа %d-%s\nФильм: %s\nСеанс: %s, %s\nМеста: %s\nСумма: %d руб.\nEmail: %s\nТелефон: %s\nСтатус оплаты: %d\nВозврат: %t",
+		sale.ExternalID, sale.Secret,
+		sale.Performance.Movie.NameSecondary,
+		cinemaTime(sale.Performance.Time).Format("02.01.2006 15:04"), sale.Performance.HallName,
+		strings.Join(seats, ", "),
+		sale.Amount, sale.Email, sale.Phone, sale.BankOrderStatus, sale.Refund)
+}
@@ -0,0 +1,67 @@
+package poravkino
+
+import (
+	"errors"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/eugenetolok/go-poravkino/pkg/model"
+	"github.com/eugenetolok/go-poravkino/pkg/telegram"
+)
+
+// withTelegramStub points bot settings to local Bot API stand-in
+func withTelegramStub(t *testing.T, chats ...int64) *telegram.Stub {
+	t.Helper()
+	stub := telegram.NewStub()
+	server := httptest.NewServer(stub)
+	settings := appSettings.BotSettings
+	appSettings.BotSettings = model.BotSettings{TelegramBotAPI: "123:token", APIURL: server.URL, AllowedChats: chats}
+	t.Cleanup(func() {
+		server.Close()
+		appSettings.BotSettings = settings
+	})
+	return stub
+}
+
+func TestAlert(t *testing.T) {
+	stub := withTelegramStub(t, 1, 2)
+	alert("test")
+	sent := stub.Sent()
+	if len(sent) != 2 || sent[0].Chat.ID != 1 || sent[1].Chat.ID != 2 || sent[0].Text != "test" {
+		t.Fatalf("sent: %+v", sent)
+	}
+}
+
+func TestBookingAvailability(t *testing.T) {
+	stub := withTelegramStub(t, 1)
+	bookingDown = false
+	t.Cleanup(func() { bookingDown = false })
+
+	down := &url.Error{Op: "Get", URL: "https://booking/api?token=secret", Err: errors.New("timeout")}
+	for _, err := range []error{nil, down, errors.New("timeout"), nil, nil} {
+		bookingAvailability(err)
+	}
+	sent := stub.Sent()
+	if len(sent) != 2 {
+		t.Fatalf("alerts are sent on every check, not on changes: %+v", sent)
+	}
+	if sent[0].Text != "Система бронирования недоступна: timeout" || sent[1].Text != "Система бронирования снова доступна" {
+		t.Fatalf("sent: %+v", sent)
+	}
+}
+
+func TestBotCommandHelp(t *testing.T) {
+	for _, text := range []string{"", "hello", "/sale", "/refund 1 2", "/unknown@bot"} {
+		if answer := botCommand(text); answer != botHelp {
+			t.Errorf("%q answered %q", text, answer)
+		}
+	}
+}
+
+func TestAllowedChat(t *testing.T) {
+	withTelegramStub(t, 1, 2)
+	if !allowedChat(2) || allowedChat(3) {
+		t.Fatal("allowed chats are not checked")
+	}
+}
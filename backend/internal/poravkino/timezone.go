@@ -0,0 +1,142 @@
+package poravkino
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+	_ "time/tzdata" // zones don't depend on server tzdata
+
+	"github.com/eugenetolok/go-poravkino/pkg/model"
+	"gorm.io/gorm"
+)
+
+var (
+	locationMu   sync.Mutex
+	locationName string
+	location     *time.Location
+	// locationWarned is set when zone change without restart is logged
+	locationWarned bool
+)
+
+// cinemaZone returns IANA name of cinema time zone, legacy offset is turned to Etc/GMT zone
+func cinemaZone() string {
+	if appSettings.SiteSettings.TimeZone != "" {
+		return appSettings.SiteSettings.TimeZone
+	}
+	if appSettings.SiteSettings.TimeZoneOffset == 0 {
+		return "UTC"
+	}
+	// Etc/GMT signs are inverted, Etc/GMT-6 is UTC+6
+	return fmt.Sprintf("Etc/GMT%+d", -appSettings.SiteSettings.TimeZoneOffset)
+}
+
+// cinemaLocation returns cinema time zone, UTC on wrong settings,
+// zone is loaded once because time.Local is set to it at start
+func cinemaLocation() *time.Location {
+	locationMu.Lock()
+	defer locationMu.Unlock()
+	name := cinemaZone()
+	if location != nil {
+		if name != locationName && !locationWarned {
+			log.Printf("time zone %s is ignored, restart to change it from %s", name, locationName)
+			locationWarned = true
+		}
+		return location
+	}
+	loaded, err := time.LoadLocation(name)
+	if err != nil {
+		log.Println("time zone settings error:", err)
+		loaded = time.UTC
+	}
+	locationName, location = name, loaded
+	return location
+}
+
+// cinemaTime returns time in cinema time zone
+func cinemaTime(t time.Time) time.Time {
+	return t.In(cinemaLocation())
+}
+
+// cinemaNow returns current time in cinema time zone
+func cinemaNow() time.Time {
+	return cinemaTime(time.Now())
+}
+
+// dayStart returns start of cinema day since midnight, night performances
+// before it belong to the previous day, 06:00 if empty
+func dayStart() time.Duration {
+	value := appSettings.SiteSettings.DayStart
+	if value == "" {
+		return 6 * time.Hour
+	}
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		log.Println("day start settings error:", err)
+		return 6 * time.Hour
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+}
+
+// cinemaDayStart returns the first instant of cinema day of date
+func cinemaDayStart(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, cinemaLocation()).Add(dayStart())
+}
+
+// cinemaDate returns date of cinema day which t belongs to, as midnight in cinema time zone
+func cinemaDate(t time.Time) time.Time {
+	wall := cinemaTime(t).Add(-dayStart())
+	return time.Date(wall.Year(), wall.Month(), wall.Day(), 0, 0, 0, 0, cinemaLocation())
+}
+
+// cinemaToday returns midnight of current calendar date in cinema time zone
+func cinemaToday() time.Time {
+	now := cinemaNow()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+}
+
+// parseCinemaDate parses booking system and query dates in cinema time zone
+func parseCinemaDate(layout, value string) (time.Time, error) {
+	return time.ParseInLocation(layout, value, cinemaLocation())
+}
+
+// timeZoneMigration is name of migrateTimeZone in migrations table
+const timeZoneMigration = "time_zone"
+
+// migrateTimeZone turns cinema wall clock stored as UTC by previous versions to instants,
+// run it once after upgrade while server is stopped, later runs do nothing
+func migrateTimeZone() {
+	if err := db.AutoMigrate(&model.Migration{}); err != nil {
+		log.Println("time zone migration error:", err)
+		return
+	}
+	var done model.Migration
+	if err := db.Where("name = ?", timeZoneMigration).First(&done).Error; err == nil {
+		log.Printf("times are already moved to %s at %s", done.Value, done.CreatedAt.Format(time.RFC3339))
+		return
+	}
+	zone := cinemaZone()
+	var performances, movies int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Performance{}).
+			Where("1 = 1").
+			Update("time", gorm.Expr("(time AT TIME ZONE 'UTC') AT TIME ZONE ?", zone))
+		if result.Error != nil {
+			return result.Error
+		}
+		performances = result.RowsAffected
+		result = tx.Model(&model.Movie{}).
+			Where("premiere > ?", time.Time{}).
+			Update("premiere", gorm.Expr("(premiere AT TIME ZONE 'UTC') AT TIME ZONE ?", zone))
+		if result.Error != nil {
+			return result.Error
+		}
+		movies = result.RowsAffected
+		return tx.Create(&model.Migration{Name: timeZoneMigration, Value: zone}).Error
+	})
+	if err != nil {
+		log.Println("time zone migration error:", err)
+		return
+	}
+	log.Printf("times of %d performances and %d premieres are moved to %s", performances, movies, zone)
+}
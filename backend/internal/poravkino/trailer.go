@@ -0,0 +1,217 @@
+package poravkino
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/eugenetolok/go-poravkino/pkg/model"
+	"github.com/eugenetolok/go-poravkino/pkg/trailer"
+	"github.com/eugenetolok/go-poravkino/pkg/utils"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+const trailerCandidatesLimit = 5
+
+// trailerSources builds sources from settings, sources without keys are skipped
+func trailerSources() []trailer.Source {
+	names := appSettings.TrailerSettings.Sources
+	if len(names) == 0 {
+		names = []string{trailer.SourceYoutube}
+	}
+	var sources []trailer.Source
+	for _, name := range names {
+		switch name {
+		case trailer.SourceYoutube:
+			if appSettings.SiteSettings.YoutubeAPIKey != "" {
+				sources = append(sources, &trailer.Youtube{APIKey: appSettings.SiteSettings.YoutubeAPIKey})
+			}
+		case trailer.SourceVK:
+			if appSettings.TrailerSettings.VKToken != "" {
+				sources = append(sources, &trailer.VK{Token: appSettings.TrailerSettings.VKToken})
+			}
+		case trailer.SourceRutube:
+			sources = append(sources, &trailer.Rutube{})
+		}
+	}
+	return sources
+}
+
+// autoTrailer adds the first found trailer to new movie
+func autoTrailer(movie model.Movie) {
+	for _, source := range trailerSources() {
+		candidates, err := source.Search(trailer.Query(movie.NameSecondary), 1)
+		if err != nil || len(candidates) == 0 {
+			continue
+		}
+		t := trailerFromCandidate(candidates[0])
+		t.MovieID = int64(movie.ID)
+		db.Create(&t)
+		return
+	}
+}
+
+func trailerFromCandidate(candidate trailer.Candidate) model.Trailer {
+	return model.Trailer{
+		Source:     candidate.Source,
+		ExternalID: candidate.ExternalID,
+		Title:      candidate.Title,
+		URL:        candidate.URL,
+		EmbedURL:   candidate.EmbedURL,
+		Preview:    candidate.Preview,
+	}
+}
+
+// migrateTrailers moves legacy youtube ids of movies to trailers
+func migrateTrailers() {
+	var movies []model.Movie
+	db.Where("youtube <> '' AND NOT EXISTS (SELECT 1 FROM trailers WHERE trailers.movie_id = movies.id)").Find(&movies)
+	for _, movie := range movies {
+		t := trailerFromCandidate(trailer.YoutubeCandidate(movie.Youtube, movie.NameSecondary, ""))
+		t.MovieID = int64(movie.ID)
+		db.Create(&t)
+	}
+	log.Printf("%d youtube trailers are migrated", len(movies))
+}
+
+// orderedTrailers preloads trailers of movie in editor order
+func orderedTrailers(db *gorm.DB) *gorm.DB {
+	return db.Order("trailers.order ASC, trailers.id ASC")
+}
+
+// trailerCandidates searches trailers in all sources or in one source
+func trailerCandidates(c echo.Context) error {
+	var movie model.Movie
+	if err := db.First(&movie, c.Param("id")).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.String(http.StatusNotFound, `{"error": "no such movie"}`)
+	}
+	query := c.QueryParam("q")
+	if query == "" {
+		query = trailer.Query(movie.NameSecondary)
+	}
+	candidates := []trailer.Candidate{}
+	for _, source := range trailerSources() {
+		if name := c.QueryParam("source"); name != "" && name != source.Name() {
+			continue
+		}
+		found, err := source.Search(query, trailerCandidatesLimit)
+		if err != nil {
+			log.Println("trailer search error:", err)
+			continue
+		}
+		candidates = append(candidates, found...)
+	}
+	return c.JSON(http.StatusOK, candidates)
+}
+
+// getTrailers returns trailers of movie
+func getTrailers(c echo.Context) error {
+	var trailers []model.Trailer
+	orderedTrailers(db).Where("movie_id = ?", c.Param("id")).Find(&trailers)
+	return c.JSON(http.StatusOK, trailers)
+}
+
+// createTrailer adds chosen candidate to the end of movie trailers
+func createTrailer(c echo.Context) error {
+	var movie model.Movie
+	if err := db.First(&movie, c.Param("id")).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.String(http.StatusNotFound, `{"error": "no such movie"}`)
+	}
+	var trailerIn model.TrailerIn
+	if err := c.Bind(&trailerIn); err != nil || trailerIn.URL == "" {
+		return c.String(http.StatusBadRequest, `{"error": "bad request"}`)
+	}
+	// upload trailers own their files, they are created by uploadTrailer only
+	if trailerIn.Source == trailer.SourceUpload {
+		return c.String(http.StatusBadRequest, `{"error": "use trailer upload"}`)
+	}
+	t := trailerFromCandidate(trailer.Candidate(trailerIn))
+	t.MovieID = int64(movie.ID)
+	t.Order = nextTrailerOrder(movie.ID)
+	db.Create(&t)
+	return c.JSON(http.StatusCreated, t)
+}
+
+// uploadTrailer saves mp4 file as trailer of movie
+func uploadTrailer(c echo.Context) error {
+	var movie model.Movie
+	if err := db.First(&movie, c.Param("id")).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.String(http.StatusNotFound, `{"error": "no such movie"}`)
+	}
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.String(http.StatusBadRequest, `{"error": "bad request"}`)
+	}
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(src, head)
+	if http.DetectContentType(head[:n]) != "video/mp4" {
+		return c.String(http.StatusBadRequest, `{"error": "only mp4 is supported"}`)
+	}
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	s := uploadStorage()
+	key := "trailers/" + utils.Sha1() + ".mp4"
+	if err := s.Put(key, src, "video/mp4"); err != nil {
+		log.Println("trailer upload error:", err)
+		return c.String(http.StatusInternalServerError, `{"error": "trailer upload error"}`)
+	}
+
+	url := s.URL(key)
+	t := model.Trailer{
+		MovieID:  int64(movie.ID),
+		Source:   trailer.SourceUpload,
+		Title:    c.FormValue("title"),
+		URL:      url,
+		EmbedURL: url,
+		Order:    nextTrailerOrder(movie.ID),
+	}
+	db.Create(&t)
+	return c.JSON(http.StatusCreated, t)
+}
+
+// orderTrailers sets order of movie trailers by ids position
+func orderTrailers(c echo.Context) error {
+	var order model.TrailerOrder
+	if err := c.Bind(&order); err != nil {
+		return c.String(http.StatusBadRequest, `{"error": "bad request"}`)
+	}
+	for index, id := range order.IDs {
+		db.Model(&model.Trailer{}).Where("id = ? AND movie_id = ?", id, c.Param("id")).Update("order", index)
+	}
+	return getTrailers(c)
+}
+
+// deleteTrailer removes trailer
+func deleteTrailer(c echo.Context) error {
+	var t model.Trailer
+	if err := db.First(&t, c.Param("id")).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.String(http.StatusNotFound, `{"error": "no such trailer"}`)
+	}
+	db.Delete(&t)
+	if t.Source == trailer.SourceUpload {
+		s := uploadStorage()
+		if key, ok := storageKey(s, t.URL); ok {
+			if err := s.Delete(key); err != nil {
+				log.Println("trailer file delete error:", err)
+			}
+		}
+	}
+	return c.String(http.StatusOK, fmt.Sprintf(`{"success":"trailer %d has been deleted"}`, t.ID))
+}
+
+// nextTrailerOrder puts new trailer after the last one, deleted trailers leave gaps
+func nextTrailerOrder(movieID uint) int64 {
+	var order int64
+	db.Model(&model.Trailer{}).Where("movie_id = ?", movieID).Select(`COALESCE(MAX("order") + 1, 0)`).Scan(&order)
+	return order
+}
@@ -0,0 +1,74 @@
+package poravkino
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/eugenetolok/go-poravkino/pkg/extapi"
+	"github.com/eugenetolok/go-poravkino/pkg/model"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// importUpcoming creates movies of announced films which have no performances yet
+func importUpcoming() {
+	films, err := extapi.GetFilms()
+	if err != nil {
+		return
+	}
+	today := cinemaToday()
+	for _, film := range films.Data {
+		premiere := film.PremiereDateRussia
+		if premiere == "" {
+			premiere = film.Premiere
+		}
+		date, err := parseCinemaDate("2006-01-02", premiere)
+		if err != nil || date.Before(today) {
+			continue
+		}
+		var count int64
+		db.Model(&model.Movie{}).Where("external_id = ?", film.ID).Count(&count)
+		if count > 0 {
+			continue
+		}
+		movie := createFilmMovie(film, film.FullSizePoster)
+		variantParent(&movie)
+	}
+}
+
+// updatePresale opens pre-sale of films with active performances before premiere
+func updatePresale() {
+	db.Model(&model.Movie{}).
+		Where("merged_into_id = 0 AND parent_id = 0").
+		Update("presale_open", gorm.Expr(`premiere > ? AND EXISTS (SELECT 1 FROM performances
+			WHERE performances.movie_id = movies.id AND performances.deleted_at IS NULL
+			AND performances.is_active AND performances.time >= ? AND performances.time < movies.premiere)`,
+			cinemaToday(), time.Now()))
+}
+
+// upcomingMovies returns coming soon films ordered by premiere
+func upcomingMovies(c echo.Context) error {
+	movies := []model.Movie{}
+	db.Preload("Trailers", orderedTrailers).
+		Where("merged_into_id = 0 AND parent_id = 0 AND show_in_upcoming = ?", true).
+		Where("premiere >= ?", cinemaToday()).
+		Order("premiere ASC, id ASC").
+		Find(&movies)
+	return c.JSON(http.StatusOK, movies)
+}
+
+// setMovieUpcoming shows or hides movie in coming soon
+func setMovieUpcoming(c echo.Context) error {
+	var movie model.Movie
+	if err := db.First(&movie, c.Param("id")).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.String(http.StatusNotFound, `{"error": "no such movie"}`)
+	}
+	var upcoming model.MovieUpcoming
+	if err := c.Bind(&upcoming); err != nil {
+		return c.String(http.StatusBadRequest, `{"error": "bad request"}`)
+	}
+	movie.ShowInUpcoming = upcoming.Show
+	db.Model(&movie).Update("show_in_upcoming", upcoming.Show)
+	return c.JSON(http.StatusOK, movie)
+}
@@ -0,0 +1,194 @@
+package poravkino
+
+import (
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/eugenetolok/go-poravkino/pkg/imaging"
+	"github.com/eugenetolok/go-poravkino/pkg/model"
+	"github.com/eugenetolok/go-poravkino/pkg/storage"
+	"github.com/eugenetolok/go-poravkino/pkg/utils"
+)
+
+const defaultUploadsGCGrace = 7 * 24 * time.Hour
+
+// uploadsGCJob is a daily cron job
+func uploadsGCJob() {
+	if !appSettings.UploadsGCSettings.Enabled {
+		return
+	}
+	collectUploads(appSettings.UploadsGCSettings.DryRun)
+}
+
+// uploadReferences collects all texts which can contain upload urls
+func uploadReferences() string {
+	var refs []string
+	var movies []model.Movie
+	db.Select("poster", "backdrop", "description").Find(&movies)
+	for _, movie := range movies {
+		refs = append(refs, movie.Poster, movie.Backdrop, movie.Description)
+	}
+	var notifications []model.Notification
+	db.Find(&notifications)
+	for _, notification := range notifications {
+		refs = append(refs, notification.PictureURL, notification.Text)
+	}
+	var trailers []model.Trailer
+	db.Select("url", "embed_url", "preview").Find(&trailers)
+	for _, trailer := range trailers {
+		refs = append(refs, trailer.URL, trailer.EmbedURL, trailer.Preview)
+	}
+	var events []model.Event
+	db.Select("poster", "description").Find(&events)
+	for _, event := range events {
+		refs = append(refs, event.Poster, event.Description)
+	}
+	var templates []model.EmailTemplate
+	db.Select("body").Find(&templates)
+	for _, emailTemplate := range templates {
+		refs = append(refs, emailTemplate.Body)
+	}
+	// revisions are kept for rollback, so their images are referenced too
+	var revisions []model.PageRevision
+	db.Select("body", "carousel", "seo_image").Find(&revisions)
+	for _, revision := range revisions {
+		refs = append(refs, revision.Body, revision.SEOImage)
+		for _, slide := range revision.Carousel {
+			refs = append(refs, slide.URL)
+		}
+	}
+	// settings are checked as text, so logo, carousel and html blocks are covered
+	if settings, err := os.ReadFile(path.Join(utils.WorkDir(), "app.yaml")); err == nil {
+		refs = append(refs, string(settings))
+	}
+	return strings.Join(refs, "\n")
+}
+
+// uploadReferenced checks all url forms of key, blured copies follow their originals
+func uploadReferenced(s storage.Storage, refs string, key string) bool {
+	dir, name := path.Split(key)
+	if strings.HasPrefix(name, "blured_") {
+		key = dir + strings.TrimPrefix(name, "blured_")
+	}
+	forms := []string{s.URL(key), "/uploads/" + key}
+	if strings.HasPrefix(key, imaging.KeyPrefix) {
+		forms = append(forms, imaging.URLPrefix+strings.TrimPrefix(key, imaging.KeyPrefix))
+	}
+	for _, form := range forms {
+		if strings.Contains(refs, form) {
+			return true
+		}
+	}
+	return false
+}
+
+// gcSource is a storage scanned by uploads gc, orphans of legacy directory are kept with its prefix
+type gcSource struct {
+	storage storage.Storage
+	prefix  string
+}
+
+// uploadsGCSources returns upload storage and dist/uploads if files were not moved from it
+func uploadsGCSources() []gcSource {
+	s := uploadStorage()
+	sources := []gcSource{{storage: s}}
+	dir := workPath(legacyUploadsDir)
+	if local, ok := s.(*storage.Local); ok && filepath.Clean(local.Dir) == filepath.Clean(dir) {
+		return sources
+	}
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		sources = append(sources, gcSource{storage: &storage.Local{Dir: dir, BaseURL: "/uploads"}, prefix: legacyUploadsDir + "/"})
+	}
+	return sources
+}
+
+// collectUploads reports orphaned uploads and removes ones orphaned longer than grace period,
+// cached variants of removed images are removed too
+func collectUploads(dryRun bool) {
+	grace := time.Duration(appSettings.UploadsGCSettings.GraceHours) * time.Hour
+	if grace <= 0 {
+		grace = defaultUploadsGCGrace
+	}
+	refs := uploadReferences()
+
+	var known []model.OrphanedUpload
+	db.Find(&known)
+	firstSeen := make(map[string]model.OrphanedUpload, len(known))
+	for _, orphan := range known {
+		firstSeen[orphan.Key] = orphan
+	}
+
+	now := time.Now()
+	var total, orphans, removed int
+	var orphanedSize, removedSize int64
+	// hashes of image originals which stay in storage
+	originals := map[string]bool{}
+	for _, source := range uploadsGCSources() {
+		s := source.storage
+		objects, err := s.List("")
+		if err != nil {
+			log.Println("uploads gc list error:", err)
+			return
+		}
+		total += len(objects)
+		for _, object := range objects {
+			key := source.prefix + object.Key
+			orphan, seen := firstSeen[key]
+			delete(firstSeen, key)
+			keep := func() {
+				if source.prefix == "" && strings.HasPrefix(object.Key, imaging.KeyPrefix) {
+					name := strings.TrimPrefix(object.Key, imaging.KeyPrefix)
+					originals[strings.TrimSuffix(name, path.Ext(name))] = true
+				}
+			}
+			if uploadReferenced(s, refs, object.Key) {
+				if seen && !dryRun {
+					db.Delete(&orphan)
+				}
+				keep()
+				continue
+			}
+			orphans++
+			orphanedSize += object.Size
+			if !seen {
+				orphan = model.OrphanedUpload{Key: key, Size: object.Size}
+				orphan.CreatedAt = now
+				if !dryRun {
+					db.Create(&orphan)
+				}
+			}
+			expired := now.Sub(orphan.CreatedAt) > grace && now.Sub(object.ModTime) > grace
+			log.Printf("orphaned upload %s, %d bytes, found %s, expired %t", key, object.Size, orphan.CreatedAt.Format(time.RFC3339), expired)
+			if !expired || dryRun {
+				keep()
+				continue
+			}
+			if err := s.Delete(object.Key); err != nil {
+				log.Println("uploads gc delete error:", err)
+				keep()
+				continue
+			}
+			db.Delete(&orphan)
+			removed++
+			removedSize += object.Size
+		}
+	}
+	if !dryRun {
+		// files removed by hand
+		for _, orphan := range firstSeen {
+			db.Delete(&orphan)
+		}
+		// variants of removed originals, including ones removed by hand
+		variants, err := imageStore().PurgeCache(func(hash string) bool { return originals[hash] })
+		if err != nil {
+			log.Println("uploads gc image cache error:", err)
+		}
+		log.Printf("uploads gc: %d cached image variants removed", variants)
+	}
+	log.Printf("uploads gc: %d objects, %d orphaned (%d bytes), %d removed (%d bytes), dry run %t",
+		total, orphans, orphanedSize, removed, removedSize, dryRun)
+}
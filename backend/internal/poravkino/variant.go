@@ -0,0 +1,64 @@
+package poravkino
+
+import (
+	"log"
+
+	"github.com/eugenetolok/go-poravkino/pkg/model"
+	"github.com/eugenetolok/go-poravkino/pkg/variant"
+)
+
+// setMovieVariant fills title key and variant label from booking name
+func setMovieVariant(movie *model.Movie) (title string) {
+	title, movie.Variant = variant.Parse(movie.Name)
+	movie.TitleKey = variant.Key(title)
+	return title
+}
+
+// variantParent returns movie which performances of variant belong to,
+// the first synced movie of the title becomes parent of later ones
+func variantParent(movie *model.Movie) model.Movie {
+	if movie.ParentID != 0 {
+		var parent model.Movie
+		if err := db.First(&parent, movie.ParentID).Error; err == nil {
+			return followMerges(parent)
+		}
+	}
+	if movie.TitleKey == "" {
+		return *movie
+	}
+	var parent model.Movie
+	err := db.Where("title_key = ? AND parent_id = 0 AND merged_into_id = 0 AND id < ?", movie.TitleKey, movie.ID).
+		Order("id ASC").
+		First(&parent).Error
+	if err != nil {
+		return *movie
+	}
+	movie.ParentID = parent.ID
+	db.Model(movie).Updates(map[string]interface{}{"parent_id": parent.ID, "is_active": false})
+	return parent
+}
+
+// hasVariantParent reports if film with the title key is already synced
+func hasVariantParent(titleKey string) bool {
+	var count int64
+	db.Model(&model.Movie{}).Where("title_key = ? AND parent_id = 0 AND merged_into_id = 0", titleKey).Count(&count)
+	return count > 0
+}
+
+// groupMovieVariants groups movies synced before variants were recognized
+func groupMovieVariants() {
+	var movies []model.Movie
+	db.Where("(title_key = '' OR title_key IS NULL) AND merged_into_id = 0").Order("id ASC").Find(&movies)
+	grouped := 0
+	for _, movie := range movies {
+		setMovieVariant(&movie)
+		db.Model(&movie).Updates(map[string]interface{}{"title_key": movie.TitleKey, "variant": movie.Variant})
+		parent := variantParent(&movie)
+		db.Model(&model.Performance{}).Where("movie_id = ?", movie.ID).
+			Updates(map[string]interface{}{"movie_id": parent.ID, "variant": movie.Variant})
+		if parent.ID != movie.ID {
+			grouped++
+		}
+	}
+	log.Printf("%d movies are grouped as variants", grouped)
+}
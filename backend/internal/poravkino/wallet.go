@@ -0,0 +1,176 @@
+package poravkino
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eugenetolok/go-poravkino/pkg/model"
+	"github.com/eugenetolok/go-poravkino/pkg/pkpass"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// walletEnabled reports if Apple Wallet passes are configured
+func walletEnabled() bool {
+	s := appSettings.WalletSettings
+	return s.PassTypeIdentifier != "" && s.TeamIdentifier != "" && s.Certificate != "" && s.Key != ""
+}
+
+// getSalePass returns .pkpass file for sale
+func getSalePass(c echo.Context) error {
+	if !walletEnabled() {
+		return c.String(http.StatusNotFound, `{"error": "wallet is not configured"}`)
+	}
+	var sale model.Sale
+	err := db.Preload("Performance.Movie").Where("secret = ?", c.QueryParam("secret")).Where("refund = ?", false).First(&sale).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.String(http.StatusNotFound, `{"error": "продажа не найдена"}`)
+	}
+	if err != nil {
+		log.Println("pkpass sale error:", err)
+		return c.String(http.StatusInternalServerError, `{"error": "internal server error"}`)
+	}
+	if sale.BankOrderStatus != 2 {
+		return c.String(http.StatusBadRequest, `{"error": "продажа не оплачена"}`)
+	}
+	data, err := buildSalePass(sale)
+	if err != nil {
+		log.Println("pkpass build error:", err)
+		return c.String(http.StatusInternalServerError, `{"error": "pass generation error"}`)
+	}
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%d-%s.pkpass"`, sale.ExternalID, sale.Secret))
+	return c.Blob(http.StatusOK, "application/vnd.apple.pkpass", data)
+}
+
+// walletSigner caches signer, it is loaded again when certificate settings or files change
+var walletSigner = struct {
+	sync.Mutex
+	key    string
+	signer *pkpass.Signer
+}{}
+
+// loadWalletSigner returns cached signer of current wallet settings
+func loadWalletSigner() (*pkpass.Signer, error) {
+	s := appSettings.WalletSettings
+	files := []string{workPath(s.Certificate), workPath(s.Key)}
+	if s.WWDRCertificate != "" {
+		files = append(files, workPath(s.WWDRCertificate))
+	}
+	var key strings.Builder
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&key, "%s:%d;", file, info.ModTime().UnixNano())
+	}
+
+	walletSigner.Lock()
+	defer walletSigner.Unlock()
+	if walletSigner.signer != nil && walletSigner.key == key.String() {
+		return walletSigner.signer, nil
+	}
+	signer, err := pkpass.LoadSigner(workPath(s.Certificate), workPath(s.Key), workPath(s.WWDRCertificate))
+	if err != nil {
+		return nil, err
+	}
+	walletSigner.key, walletSigner.signer = key.String(), signer
+	return signer, nil
+}
+
+func buildSalePass(sale model.Sale) ([]byte, error) {
+	s := appSettings.WalletSettings
+	signer, err := loadWalletSigner()
+	if err != nil {
+		return nil, err
+	}
+	images, err := walletImages(workPath(s.ImagesDir))
+	if err != nil {
+		return nil, err
+	}
+	return pkpass.Build(salePass(sale), images, signer)
+}
+
+// salePass fills pass.json with sale, performance and tickets data
+func salePass(sale model.Sale) pkpass.Pass {
+	s := appSettings.WalletSettings
+	start := cinemaTime(sale.Performance.Time)
+	end := start.Add(time.Minute * time.Duration(sale.Performance.Movie.Duration+sale.Performance.Movie.AddDuration))
+	movieName := sale.Performance.Movie.NameSecondary
+	if movieName == "" {
+		movieName = sale.Performance.Movie.Name
+	}
+	code := fmt.Sprintf("%d-%s", sale.ExternalID, sale.Secret)
+
+	var seats []string
+	for _, ticket := range sale.Tickets {
+		seats = append(seats, fmt.Sprintf("ряд %s, место %s", ticket.Row, ticket.Seat))
+	}
+
+	pass := pkpass.Pass{
+		PassTypeIdentifier: s.PassTypeIdentifier,
+		SerialNumber:       sale.Secret,
+		TeamIdentifier:     s.TeamIdentifier,
+		OrganizationName:   s.OrganizationName,
+		Description:        "Билеты: " + movieName,
+		LogoText:           appSettings.CinemaSettings.CinemaName,
+		ForegroundColor:    s.ForegroundColor,
+		BackgroundColor:    s.BackgroundColor,
+		LabelColor:         s.LabelColor,
+		RelevantDate:       start.Format(time.RFC3339),
+		ExpirationDate:     end.Format(time.RFC3339),
+		Barcodes: []pkpass.Barcode{{
+			Format:          pkpass.BarcodeQR,
+			Message:         sale.Secret,
+			MessageEncoding: "iso-8859-1",
+			AltText:         code,
+		}},
+		EventTicket: &pkpass.PassFields{
+			PrimaryFields: []pkpass.Field{
+				{Key: "movie", Label: "Фильм", Value: movieName},
+			},
+			SecondaryFields: []pkpass.Field{
+				{Key: "time", Label: "Начало", Value: start.Format(time.RFC3339), DateStyle: "PKDateStyleMedium", TimeStyle: "PKDateStyleShort"},
+				{Key: "hall", Label: "Зал", Value: sale.Performance.HallName},
+			},
+			AuxiliaryFields: []pkpass.Field{
+				{Key: "seats", Label: "Места", Value: strings.Join(seats, "; ")},
+				{Key: "amount", Label: "Сумма", Value: fmt.Sprintf("%d руб.", sale.Amount)},
+			},
+			BackFields: []pkpass.Field{
+				{Key: "code", Label: "Код", Value: code},
+				{Key: "address", Label: "Адрес", Value: appSettings.CinemaSettings.Address},
+				{Key: "tickets", Label: "Билеты", Value: fmt.Sprintf("https://%s/api/sales/code/lost?secret=%s", appSettings.CinemaSettings.DomainName, sale.Secret)},
+			},
+		},
+	}
+	pass.Barcode = &pass.Barcodes[0]
+	if s.Latitude != 0 || s.Longitude != 0 {
+		pass.Locations = []pkpass.Location{{Latitude: s.Latitude, Longitude: s.Longitude, RelevantText: movieName}}
+	}
+	return pass
+}
+
+// walletImages reads all png images of the pass
+func walletImages(dir string) (map[string][]byte, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.png"))
+	if err != nil {
+		return nil, err
+	}
+	images := make(map[string][]byte, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		images[filepath.Base(file)] = data
+	}
+	return images, nil
+}
@@ -0,0 +1,152 @@
+package attributes
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eugenetolok/go-poravkino/pkg/model"
+)
+
+// Attribute names of rules
+const (
+	Format           = "format"
+	OriginalLanguage = "original_language"
+	Subtitles        = "subtitles"
+	AudioDescription = "audio_description"
+	Relaxed          = "relaxed"
+	Kids             = "kids"
+	LateShow         = "late_show"
+)
+
+// DefaultRules are used when settings have no rules, later rules win for format
+var DefaultRules = []model.AttributeRule{
+	{Attribute: Format, Value: "3D", Field: "variant", Pattern: `3D`},
+	{Attribute: Format, Value: "Dolby Atmos", Pattern: `(?i)dolby`},
+	{Attribute: Format, Value: "4DX", Pattern: `(?i)4DX`},
+	{Attribute: Format, Value: "IMAX", Pattern: `(?i)imax`},
+	{Attribute: OriginalLanguage, Field: "variant", Pattern: `оригинал`},
+	{Attribute: Subtitles, Field: "variant", Pattern: `субтитры`},
+	{Attribute: AudioDescription, Field: "movie", Pattern: `(?i)тифлокоммент|аудиоописан`},
+	{Attribute: Relaxed, Field: "movie", Pattern: `(?i)щадящ|relaxed`},
+	{Attribute: Kids, Pattern: `(?i)детск|kids|мульт-?утро`},
+}
+
+const (
+	defaultLateShowHour = 22
+	defaultLateShowAge  = 18
+	nightEndHour        = 5
+)
+
+// Input is what is known about performance on sync
+type Input struct {
+	Movie   string // booking system film name
+	Hall    string
+	Variant string
+	ThreeD  bool
+	Time    time.Time // cinema wall clock
+	Age     int64
+}
+
+var (
+	mu       sync.Mutex
+	compiled = map[string]*regexp.Regexp{}
+)
+
+func compile(pattern string) (*regexp.Regexp, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if re, ok := compiled[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	compiled[pattern] = re
+	return re, nil
+}
+
+// Validate checks rule attribute and pattern
+func Validate(rule model.AttributeRule) error {
+	switch rule.Attribute {
+	case Format:
+		if rule.Value == "" {
+			return fmt.Errorf("format rule %q has no value", rule.Pattern)
+		}
+	case OriginalLanguage, Subtitles, AudioDescription, Relaxed, Kids, LateShow:
+	default:
+		return fmt.Errorf("unknown attribute %q", rule.Attribute)
+	}
+	switch rule.Field {
+	case "", "movie", "hall", "variant":
+	default:
+		return fmt.Errorf("unknown field %q", rule.Field)
+	}
+	_, err := compile(rule.Pattern)
+	return err
+}
+
+// Derive applies rules to input, wrong rules are skipped and returned as errors
+func Derive(s model.PerformanceSettings, in Input) (model.PerformanceAttributes, []error) {
+	a := model.PerformanceAttributes{Format: "2D"}
+	if in.ThreeD {
+		a.Format = "3D"
+	}
+	lateShowHour, lateShowAge := s.LateShowHour, s.LateShowAge
+	if lateShowHour == 0 {
+		lateShowHour = defaultLateShowHour
+	}
+	if lateShowAge == 0 {
+		lateShowAge = defaultLateShowAge
+	}
+	hour := int64(in.Time.Hour())
+	a.LateShow = in.Age >= lateShowAge && (hour >= lateShowHour || hour < nightEndHour)
+
+	rules := s.Rules
+	if len(rules) == 0 {
+		rules = DefaultRules
+	}
+	var errs []error
+	for _, rule := range rules {
+		if err := Validate(rule); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		re, _ := compile(rule.Pattern)
+		if !re.MatchString(field(in, rule.Field)) {
+			continue
+		}
+		switch rule.Attribute {
+		case Format:
+			a.Format = rule.Value
+		case OriginalLanguage:
+			a.OriginalLanguage = true
+		case Subtitles:
+			a.Subtitles = true
+		case AudioDescription:
+			a.AudioDescription = true
+		case Relaxed:
+			a.Relaxed = true
+		case Kids:
+			a.Kids = true
+		case LateShow:
+			a.LateShow = true
+		}
+	}
+	return a, errs
+}
+
+func field(in Input, name string) string {
+	switch name {
+	case "movie":
+		return in.Movie
+	case "hall":
+		return in.Hall
+	case "variant":
+		return in.Variant
+	}
+	return strings.Join([]string{in.Movie, in.Hall, in.Variant}, "\n")
+}
@@ -0,0 +1,86 @@
+package attributes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eugenetolok/go-poravkino/pkg/model"
+)
+
+func TestDerive(t *testing.T) {
+	evening := time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC)
+	late := time.Date(2024, 5, 1, 23, 0, 0, 0, time.UTC)
+	night := time.Date(2024, 5, 2, 1, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		settings model.PerformanceSettings
+		in       Input
+		want     model.PerformanceAttributes
+	}{
+		{"2D by default", model.PerformanceSettings{}, Input{Movie: "Дюна", Time: evening}, model.PerformanceAttributes{Format: "2D"}},
+		{"3D flag", model.PerformanceSettings{}, Input{Movie: "Дюна", ThreeD: true, Time: evening}, model.PerformanceAttributes{Format: "3D"}},
+		{"3D variant", model.PerformanceSettings{}, Input{Movie: "Дюна", Variant: "3D, субтитры", Time: evening}, model.PerformanceAttributes{Format: "3D", Subtitles: true}},
+		{"IMAX hall wins", model.PerformanceSettings{}, Input{Movie: "Дюна", Hall: "IMAX", Variant: "3D", Time: evening}, model.PerformanceAttributes{Format: "IMAX"}},
+		{"original", model.PerformanceSettings{}, Input{Movie: "Дюна", Variant: "оригинал", Time: evening}, model.PerformanceAttributes{Format: "2D", OriginalLanguage: true}},
+		{"audio description", model.PerformanceSettings{}, Input{Movie: "Дюна с тифлокомментарием", Time: evening}, model.PerformanceAttributes{Format: "2D", AudioDescription: true}},
+		{"relaxed", model.PerformanceSettings{}, Input{Movie: "Щадящий сеанс: Дюна", Time: evening}, model.PerformanceAttributes{Format: "2D", Relaxed: true}},
+		{"kids", model.PerformanceSettings{}, Input{Movie: "Мульт-утро", Time: evening}, model.PerformanceAttributes{Format: "2D", Kids: true}},
+		{"late show", model.PerformanceSettings{}, Input{Movie: "Дюна", Time: late, Age: 18}, model.PerformanceAttributes{Format: "2D", LateShow: true}},
+		{"night show", model.PerformanceSettings{}, Input{Movie: "Дюна", Time: night, Age: 18}, model.PerformanceAttributes{Format: "2D", LateShow: true}},
+		{"late show of young age", model.PerformanceSettings{}, Input{Movie: "Дюна", Time: late, Age: 16}, model.PerformanceAttributes{Format: "2D"}},
+		{"late show hour setting", model.PerformanceSettings{LateShowHour: 19, LateShowAge: 16}, Input{Movie: "Дюна", Time: evening, Age: 16}, model.PerformanceAttributes{Format: "2D", LateShow: true}},
+		{
+			"custom rules replace defaults",
+			model.PerformanceSettings{Rules: []model.AttributeRule{{Attribute: Format, Value: "ScreenX", Field: "hall", Pattern: `(?i)screenx`}}},
+			Input{Movie: "Дюна IMAX", Hall: "ScreenX", Variant: "субтитры", Time: evening},
+			model.PerformanceAttributes{Format: "ScreenX"},
+		},
+	}
+	for _, tt := range tests {
+		got, errs := Derive(tt.settings, tt.in)
+		if len(errs) > 0 {
+			t.Errorf("%s: %v", tt.name, errs)
+		}
+		if got != tt.want {
+			t.Errorf("%s: %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDeriveSkipsWrongRules(t *testing.T) {
+	settings := model.PerformanceSettings{Rules: []model.AttributeRule{
+		{Attribute: Kids, Pattern: `(`},
+		{Attribute: Subtitles, Pattern: `субтитры`},
+	}}
+	got, errs := Derive(settings, Input{Variant: "субтитры"})
+	if len(errs) != 1 {
+		t.Fatalf("errors: %v", errs)
+	}
+	if !got.Subtitles || got.Kids {
+		t.Fatalf("attributes: %+v", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		rule model.AttributeRule
+		ok   bool
+	}{
+		{model.AttributeRule{Attribute: Format, Value: "IMAX", Pattern: `imax`}, true},
+		{model.AttributeRule{Attribute: Kids, Field: "movie", Pattern: `детск`}, true},
+		{model.AttributeRule{Attribute: Format, Pattern: `imax`}, false},
+		{model.AttributeRule{Attribute: "color", Pattern: `x`}, false},
+		{model.AttributeRule{Attribute: Kids, Field: "genre", Pattern: `x`}, false},
+		{model.AttributeRule{Attribute: Kids, Pattern: `[`}, false},
+	}
+	for _, tt := range tests {
+		if err := Validate(tt.rule); (err == nil) != tt.ok {
+			t.Errorf("Validate(%+v) = %v", tt.rule, err)
+		}
+	}
+	for _, rule := range DefaultRules {
+		if err := Validate(rule); err != nil {
+			t.Errorf("default rule: %v", err)
+		}
+	}
+}
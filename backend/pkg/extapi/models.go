@@ -75,20 +75,27 @@ type (
 	Movie struct {
 		Code    int    `json:"code"`
 		Message string `json:"message"`
-		Data    struct {
-			ID                 int64  `json:"id"`
-			Name               string `json:"name_short"`
-			NameSecondary      string `json:"name_secondary"`
-			AgeLimit           int64  `json:"ageLimit"`
-			AnnotationFull     string `json:"annotationFull"`
-			Genre              string `json:"genre"`
-			Duration           string `json:"duration"`
-			PremiereDateRussia string `json:"premiereDateRussia"`
-			Premiere           string `json:"premiere"`
-			FullSizePoster     string `json:"fullSizePoster"`
-			PushkinCardEventId string `json:"pushkinCardEventId"`
-			RentCertificate    string `json:"rentCertificate"`
-		} `json:"data"`
+		Data    Film   `json:"data"`
+	}
+	// Films - films announced in booking system, with or without performances
+	Films struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Data    []Film `json:"data"`
+	}
+	Film struct {
+		ID                 int64  `json:"id"`
+		Name               string `json:"name_short"`
+		NameSecondary      string `json:"name_secondary"`
+		AgeLimit           int64  `json:"ageLimit"`
+		AnnotationFull     string `json:"annotationFull"`
+		Genre              string `json:"genre"`
+		Duration           string `json:"duration"`
+		PremiereDateRussia string `json:"premiereDateRussia"`
+		Premiere           string `json:"premiere"`
+		FullSizePoster     string `json:"fullSizePoster"`
+		PushkinCardEventId string `json:"pushkinCardEventId"`
+		RentCertificate    string `json:"rentCertificate"`
 	}
 
 	Schedule struct {
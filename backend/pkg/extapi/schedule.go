@@ -8,13 +8,14 @@ import (
 	"github.com/eugenetolok/go-poravkino/pkg/utils"
 )
 
-func GetSchedule() Schedule {
+// GetSchedule - function which gets schedule for month, error means booking system is unreachable
+func GetSchedule() (Schedule, error) {
 	var schedule Schedule
 	err := utils.GetJSON(settings.ExtAPIURL+"schedule/?from="+time.Now().Add(time.Hour*-12).Format("2006-01-02")+"&to="+time.Now().AddDate(0, 1, 0).Format("2006-01-02")+"&token="+apiKey("base", settings.Ais[0]), &schedule)
 	if err != nil {
 		log.Println("couldn't connect to booking system api")
 	}
-	return schedule
+	return schedule, err
 }
 
 // GetMovie - function which gets movie info from extapi
@@ -23,3 +24,13 @@ func GetMovie(movieID int64) Movie {
 	utils.GetJSON(settings.ExtAPIURL+"films/?id="+strconv.FormatInt(movieID, 10)+"&token="+apiKey("base", settings.Ais[0]), &movie)
 	return movie
 }
+
+// GetFilms - function which gets films announced in booking system, including ones without performances
+func GetFilms() (Films, error) {
+	var films Films
+	err := utils.GetJSON(settings.ExtAPIURL+"films/?token="+apiKey("base", settings.Ais[0]), &films)
+	if err != nil {
+		log.Println("couldn't get films from booking system api")
+	}
+	return films, err
+}
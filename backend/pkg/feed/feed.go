@@ -0,0 +1,133 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Feed formats
+const (
+	FormatJSON = "json"
+	FormatXML  = "xml"
+	FormatCSV  = "csv"
+)
+
+// ErrFormat is returned for unknown format
+var ErrFormat = errors.New("unknown feed format")
+
+// Formats lists supported formats
+var Formats = []string{FormatJSON, FormatXML, FormatCSV}
+
+type (
+	// Schedule is a neutral schedule export, partners get it in their format
+	Schedule struct {
+		XMLName   xml.Name  `json:"-" xml:"feed"`
+		Generated time.Time `json:"generated" xml:"generated,attr"`
+		Cinema    Cinema    `json:"cinema" xml:"place"`
+		Movies    []Movie   `json:"events" xml:"events>event"`
+		Sessions  []Session `json:"sessions" xml:"schedule>session"`
+	}
+	// Cinema is a place of sessions
+	Cinema struct {
+		ID      int64  `json:"id" xml:"id,attr"`
+		Name    string `json:"name" xml:"title"`
+		City    string `json:"city" xml:"city"`
+		Address string `json:"address" xml:"address"`
+		URL     string `json:"url" xml:"url"`
+	}
+	// Movie is an event of feed
+	Movie struct {
+		ID            uint     `json:"id" xml:"id,attr"`
+		Title         string   `json:"title" xml:"title"`
+		OriginalTitle string   `json:"original_title,omitempty" xml:"original_title,omitempty"`
+		Age           int64    `json:"age" xml:"age_restriction"`
+		Duration      int64    `json:"duration" xml:"duration"` // minutes
+		Genres        []string `json:"genres" xml:"genres>genre"`
+		Description   string   `json:"description" xml:"description"`
+		Poster        string   `json:"poster" xml:"image"`
+		Premiere      string   `json:"premiere,omitempty" xml:"premiere,omitempty"` // YYYY-MM-DD
+		Pushkin       bool     `json:"pushkin" xml:"pushkin_card"`
+		URL           string   `json:"url" xml:"url"`
+	}
+	// Session is a performance with checkout link
+	Session struct {
+		ID         uint      `json:"id" xml:"id,attr"`
+		MovieID    int64     `json:"event_id" xml:"event,attr"`
+		Time       time.Time `json:"time" xml:"date,attr"`
+		Hall       string    `json:"hall" xml:"hall"`
+		Format     string    `json:"format" xml:"format"`
+		Variant    string    `json:"variant,omitempty" xml:"variant,omitempty"`
+		Price      int64     `json:"price" xml:"price"`
+		SeatsFree  int64     `json:"seats_free" xml:"seats_free"`
+		SeatsTotal int64     `json:"seats_total" xml:"seats_total"`
+		URL        string    `json:"url" xml:"ticket_url"`
+	}
+)
+
+// ContentType returns content type of format
+func ContentType(format string) string {
+	switch format {
+	case FormatXML:
+		return "application/xml; charset=utf-8"
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	}
+	return "application/json; charset=utf-8"
+}
+
+// Encode returns schedule in format
+func Encode(format string, s Schedule) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		return json.Marshal(s)
+	case FormatXML:
+		data, err := xml.MarshalIndent(s, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(xml.Header), data...), nil
+	case FormatCSV:
+		return encodeCSV(s)
+	}
+	return nil, ErrFormat
+}
+
+// encodeCSV writes one row per session with movie columns, as spreadsheets expect
+func encodeCSV(s Schedule) ([]byte, error) {
+	movies := make(map[int64]Movie, len(s.Movies))
+	for _, movie := range s.Movies {
+		movies[int64(movie.ID)] = movie
+	}
+	buf := new(bytes.Buffer)
+	w := csv.NewWriter(buf)
+	w.Write([]string{"session_id", "time", "hall", "format", "variant", "price", "seats_free", "seats_total", "url",
+		"event_id", "title", "original_title", "age", "duration", "genres"})
+	for _, session := range s.Sessions {
+		movie := movies[session.MovieID]
+		w.Write([]string{
+			strconv.FormatUint(uint64(session.ID), 10),
+			session.Time.Format(time.RFC3339),
+			session.Hall,
+			session.Format,
+			session.Variant,
+			strconv.FormatInt(session.Price, 10),
+			strconv.FormatInt(session.SeatsFree, 10),
+			strconv.FormatInt(session.SeatsTotal, 10),
+			session.URL,
+			strconv.FormatInt(session.MovieID, 10),
+			movie.Title,
+			movie.OriginalTitle,
+			strconv.FormatInt(movie.Age, 10),
+			strconv.FormatInt(movie.Duration, 10),
+			strings.Join(movie.Genres, ", "),
+		})
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
@@ -0,0 +1,100 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testSchedule() Schedule {
+	start := time.Date(2024, 5, 1, 19, 30, 0, 0, time.UTC)
+	return Schedule{
+		Generated: start,
+		Cinema:    Cinema{ID: 7, Name: "Кино", City: "Омск"},
+		Movies: []Movie{
+			{ID: 1, Title: "Дюна, часть 2", Age: 12, Duration: 166, Genres: []string{"фантастика", "драма"}},
+		},
+		Sessions: []Session{
+			{ID: 10, MovieID: 1, Time: start, Hall: "Зал 1", Format: "IMAX", Price: 500, SeatsFree: 3, SeatsTotal: 100, URL: "https://cinema.ru/performance/10"},
+			{ID: 11, MovieID: 2, Time: start.Add(time.Hour), Hall: "Зал \"2\"", Format: "2D", Price: 300},
+		},
+	}
+}
+
+func TestEncode(t *testing.T) {
+	s := testSchedule()
+	tests := []struct {
+		format string
+		check  func(t *testing.T, data []byte)
+	}{
+		{FormatJSON, func(t *testing.T, data []byte) {
+			var got Schedule
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			if len(got.Movies) != 1 || len(got.Sessions) != 2 || got.Sessions[0].MovieID != 1 || !got.Sessions[0].Time.Equal(s.Sessions[0].Time) {
+				t.Fatalf("json: %s", data)
+			}
+		}},
+		{FormatXML, func(t *testing.T, data []byte) {
+			if !strings.HasPrefix(string(data), xml.Header+"<feed") {
+				t.Fatalf("xml header: %.60s", data)
+			}
+			var got Schedule
+			if err := xml.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Cinema.Name != "Кино" || len(got.Movies[0].Genres) != 2 || len(got.Sessions) != 2 || got.Sessions[1].Hall != `Зал "2"` {
+				t.Fatalf("xml: %s", data)
+			}
+		}},
+		{FormatCSV, func(t *testing.T, data []byte) {
+			rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rows) != 3 {
+				t.Fatalf("%d rows", len(rows))
+			}
+			want := []string{"10", "2024-05-01T19:30:00Z", "Зал 1", "IMAX", "", "500", "3", "100", "https://cinema.ru/performance/10",
+				"1", "Дюна, часть 2", "", "12", "166", "фантастика, драма"}
+			if strings.Join(rows[1], "|") != strings.Join(want, "|") {
+				t.Fatalf("row is %q", rows[1])
+			}
+			// session of unknown movie keeps its columns empty
+			if rows[2][9] != "2" || rows[2][10] != "" || rows[2][2] != `Зал "2"` {
+				t.Fatalf("row is %q", rows[2])
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			data, err := Encode(tt.format, s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, data)
+		})
+	}
+	if _, err := Encode("yaml", s); err != ErrFormat {
+		t.Fatalf("unknown format: %v", err)
+	}
+}
+
+func TestContentType(t *testing.T) {
+	tests := map[string]string{
+		FormatJSON: "application/json; charset=utf-8",
+		FormatXML:  "application/xml; charset=utf-8",
+		FormatCSV:  "text/csv; charset=utf-8",
+		"":         "application/json; charset=utf-8",
+	}
+	for format, want := range tests {
+		if got := ContentType(format); got != want {
+			t.Errorf("ContentType(%q) = %q, want %q", format, got, want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"time"
+)
+
+const (
+	// ContentType of iCalendar documents
+	ContentType = "text/calendar; charset=utf-8"
+	timeFormat  = "20060102T150405Z"
+	lineLimit   = 75
+)
+
+type (
+	// Calendar is VCALENDAR object with events
+	Calendar struct {
+		ProdID string
+		Name   string
+		Method string // PUBLISH for feeds and attachments
+		Events []Event
+	}
+	// Event is VEVENT object, UID must stay the same between updates
+	Event struct {
+		UID          string
+		Start        time.Time
+		End          time.Time
+		Summary      string
+		Description  string
+		Location     string
+		URL          string
+		LastModified time.Time
+		Cancelled    bool
+	}
+)
+
+// Encode returns calendar in iCalendar (RFC 5545) format
+func (c Calendar) Encode() []byte {
+	buf := new(bytes.Buffer)
+	writeLine(buf, "BEGIN", "VCALENDAR")
+	writeLine(buf, "VERSION", "2.0")
+	writeLine(buf, "PRODID", c.ProdID)
+	writeLine(buf, "CALSCALE", "GREGORIAN")
+	if c.Method != "" {
+		writeLine(buf, "METHOD", c.Method)
+	}
+	if c.Name != "" {
+		writeLine(buf, "X-WR-CALNAME", escape(c.Name))
+	}
+	stamp := time.Now().UTC().Format(timeFormat)
+	for _, e := range c.Events {
+		writeLine(buf, "BEGIN", "VEVENT")
+		writeLine(buf, "UID", e.UID)
+		writeLine(buf, "DTSTAMP", stamp)
+		writeLine(buf, "DTSTART", e.Start.UTC().Format(timeFormat))
+		writeLine(buf, "DTEND", e.End.UTC().Format(timeFormat))
+		if !e.LastModified.IsZero() {
+			writeLine(buf, "LAST-MODIFIED", e.LastModified.UTC().Format(timeFormat))
+		}
+		writeLine(buf, "SUMMARY", escape(e.Summary))
+		if e.Description != "" {
+			writeLine(buf, "DESCRIPTION", escape(e.Description))
+		}
+		if e.Location != "" {
+			writeLine(buf, "LOCATION", escape(e.Location))
+		}
+		if e.URL != "" {
+			writeLine(buf, "URL", e.URL)
+		}
+		if e.Cancelled {
+			writeLine(buf, "STATUS", "CANCELLED")
+		} else {
+			writeLine(buf, "STATUS", "CONFIRMED")
+		}
+		writeLine(buf, "END", "VEVENT")
+	}
+	writeLine(buf, "END", "VCALENDAR")
+	return buf.Bytes()
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+// writeLine writes content line folded to 75 octets without breaking UTF-8 runes
+func writeLine(buf *bytes.Buffer, name, value string) {
+	line := name + ":" + value
+	limit := lineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+		// continuation lines start with a space which counts to the limit
+		limit = lineLimit - 1
+	}
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
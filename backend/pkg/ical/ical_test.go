@@ -0,0 +1,98 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// unfold joins folded content lines back
+func unfold(data []byte) []string {
+	return strings.Split(strings.TrimSuffix(strings.ReplaceAll(string(data), "\r\n ", ""), "\r\n"), "\r\n")
+}
+
+func TestEncode(t *testing.T) {
+	start := time.Date(2024, 5, 1, 19, 30, 0, 0, time.FixedZone("UTC+6", 6*60*60))
+	calendar := Calendar{
+		ProdID: "-//Cinema//Tickets//RU",
+		Name:   "Билеты",
+		Method: "PUBLISH",
+		Events: []Event{
+			{UID: "sale-1@cinema", Start: start, End: start.Add(2 * time.Hour), Summary: "Дюна; часть 2, IMAX", Description: "Зал 1\nРяд 5", URL: "https://cinema.ru/tickets"},
+			{UID: "sale-2@cinema", Start: start, End: start.Add(time.Hour), Summary: "Возврат", Cancelled: true},
+		},
+	}
+	data := calendar.Encode()
+	lines := unfold(data)
+	want := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Cinema//Tickets//RU",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:Билеты",
+		"BEGIN:VEVENT",
+		"UID:sale-1@cinema",
+		"DTSTAMP:*",
+		"DTSTART:20240501T133000Z",
+		"DTEND:20240501T153000Z",
+		`SUMMARY:Дюна\; часть 2\, IMAX`,
+		`DESCRIPTION:Зал 1\nРяд 5`,
+		"URL:https://cinema.ru/tickets",
+		"STATUS:CONFIRMED",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:sale-2@cinema",
+		"DTSTAMP:*",
+		"DTSTART:20240501T133000Z",
+		"DTEND:20240501T143000Z",
+		"SUMMARY:Возврат",
+		"STATUS:CANCELLED",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("%d lines, want %d:\n%s", len(lines), len(want), data)
+	}
+	for i := range want {
+		if prefix := strings.TrimSuffix(want[i], "*"); prefix != want[i] && strings.HasPrefix(lines[i], prefix) {
+			continue
+		}
+		if lines[i] != want[i] {
+			t.Errorf("line %d is %q, want %q", i, lines[i], want[i])
+		}
+	}
+}
+
+func TestWriteLine(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"short", "Дюна"},
+		{"ascii", strings.Repeat("a", 200)},
+		{"cyrillic", strings.Repeat("ж", 100)},
+		{"mixed", "a" + strings.Repeat("жa", 60)},
+	}
+	for _, tt := range tests {
+		buf := new(bytes.Buffer)
+		writeLine(buf, "SUMMARY", tt.value)
+		raw := buf.String()
+		if !strings.HasSuffix(raw, "\r\n") {
+			t.Errorf("%s: line is not terminated", tt.name)
+		}
+		for _, line := range strings.Split(strings.TrimSuffix(raw, "\r\n"), "\r\n") {
+			if len(line) > lineLimit {
+				t.Errorf("%s: line of %d octets", tt.name, len(line))
+			}
+			if !utf8.ValidString(line) {
+				t.Errorf("%s: rune is broken in %q", tt.name, line)
+			}
+		}
+		if got := unfold(buf.Bytes()); len(got) != 1 || got[0] != "SUMMARY:"+tt.value {
+			t.Errorf("%s: unfolded to %q", tt.name, got)
+		}
+	}
+}
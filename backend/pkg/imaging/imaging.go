@@ -0,0 +1,156 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"github.com/esimov/stackblur-go"
+	"github.com/nfnt/resize"
+	_ "golang.org/x/image/webp" // register webp decoder
+)
+
+// Fit modes
+const (
+	FitContain = "contain" // image fits into width x height box
+	FitCover   = "cover"   // image covers the box and is cropped by center
+)
+
+// Limits of variant parameters and originals
+const (
+	MaxSize   = 2560
+	MaxBlur   = 100
+	BlurStep  = 5
+	MaxPixels = 50_000_000 // decoded originals take 4 bytes per pixel
+)
+
+// Sizes are width and height buckets, requested sizes are rounded up to them,
+// so public endpoint caches only a few variants of each image
+var Sizes = []uint{160, 320, 640, 960, 1280, 1920, MaxSize}
+
+var (
+	// ErrFormat is returned for images which are not jpeg, png or webp
+	ErrFormat = errors.New("unsupported image format")
+	// ErrTooLarge is returned for images with more than MaxPixels pixels or bigger uploads
+	ErrTooLarge = errors.New("image is too large")
+	// ErrOptions is returned for wrong variant options
+	ErrOptions = errors.New("wrong image options")
+)
+
+// Options of image variant, zero value means original size
+type Options struct {
+	Width  uint
+	Height uint
+	Fit    string
+	Blur   uint32
+}
+
+// Validate checks options against limits, rounds sizes up to buckets,
+// blur to BlurStep and sets default fit
+func (o *Options) Validate() error {
+	if o.Width > MaxSize || o.Height > MaxSize {
+		return fmt.Errorf("%w: size is bigger than %d", ErrOptions, MaxSize)
+	}
+	if o.Blur > MaxBlur {
+		return fmt.Errorf("%w: blur is bigger than %d", ErrOptions, MaxBlur)
+	}
+	switch o.Fit {
+	case "":
+		o.Fit = FitContain
+	case FitContain, FitCover:
+	default:
+		return fmt.Errorf("%w: unknown fit %q", ErrOptions, o.Fit)
+	}
+	o.Width, o.Height = bucket(o.Width), bucket(o.Height)
+	o.Blur = (o.Blur + BlurStep - 1) / BlurStep * BlurStep
+	return nil
+}
+
+// bucket returns the smallest size bucket which is not less than size, 0 stays original
+func bucket(size uint) uint {
+	if size == 0 {
+		return 0
+	}
+	for _, b := range Sizes {
+		if size <= b {
+			return b
+		}
+	}
+	return MaxSize
+}
+
+// Key is a part of cache file name
+func (o Options) Key() string {
+	return fmt.Sprintf("%dx%d_%s_%d", o.Width, o.Height, o.Fit, o.Blur)
+}
+
+// Decode decodes jpeg, png or webp image, dimensions are checked before pixels are decoded
+func Decode(r io.Reader) (image.Image, string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, "", err
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if errors.Is(err, image.ErrFormat) {
+		return nil, "", ErrFormat
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(config.Width)*int64(config.Height) > MaxPixels {
+		return nil, "", ErrTooLarge
+	}
+	return image.Decode(bytes.NewReader(data))
+}
+
+// Process resizes, crops and blurs image
+func Process(img image.Image, o Options) (image.Image, error) {
+	if o.Width != 0 || o.Height != 0 {
+		if o.Fit == FitCover && o.Width != 0 && o.Height != 0 {
+			img = cover(img, o.Width, o.Height)
+		} else if o.Width != 0 && o.Height != 0 {
+			img = resize.Thumbnail(o.Width, o.Height, img, resize.Lanczos3)
+		} else {
+			img = resize.Resize(o.Width, o.Height, img, resize.Lanczos3)
+		}
+	}
+	if o.Blur > 0 {
+		return stackblur.Process(img, o.Blur)
+	}
+	return img, nil
+}
+
+// cover scales image to cover the box and crops the middle
+func cover(img image.Image, width, height uint) image.Image {
+	b := img.Bounds()
+	if uint(b.Dx())*height > uint(b.Dy())*width {
+		img = resize.Resize(0, height, img, resize.Lanczos3)
+	} else {
+		img = resize.Resize(width, 0, img, resize.Lanczos3)
+	}
+	b = img.Bounds()
+	x := b.Min.X + (b.Dx()-int(width))/2
+	y := b.Min.Y + (b.Dy()-int(height))/2
+	crop := image.Rect(x, y, x+int(width), y+int(height))
+	if sub, ok := img.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(crop)
+	}
+	return img
+}
+
+// Encode writes png for png originals to keep transparency and jpeg for others
+func Encode(img image.Image, format string) ([]byte, string, error) {
+	var buf bytes.Buffer
+	if format == "png" {
+		err := png.Encode(&buf, img)
+		return buf.Bytes(), "image/png", err
+	}
+	err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+	return buf.Bytes(), "image/jpeg", err
+}
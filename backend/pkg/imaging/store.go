@@ -0,0 +1,184 @@
+package imaging
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/eugenetolok/go-poravkino/pkg/storage"
+)
+
+// URLPrefix is a path of images endpoint
+const URLPrefix = "/api/images/"
+
+// KeyPrefix is a storage key prefix of originals
+const KeyPrefix = "images/"
+
+const maxUpload = 32 << 20
+
+var hashName = regexp.MustCompile(`^[0-9a-f]{64}\.(jpg|png|webp)$`)
+
+// Store keeps originals in storage under content hash and caches variants on local disk
+type Store struct {
+	Storage  storage.Storage
+	CacheDir string
+}
+
+// Save stores original once and returns its public url
+func (s *Store) Save(r io.Reader) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxUpload+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxUpload {
+		return "", fmt.Errorf("%w: bigger than %d bytes", ErrTooLarge, maxUpload)
+	}
+	_, format, err := Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	ext := format
+	if format == "jpeg" {
+		ext = "jpg"
+	}
+	sum := sha256.Sum256(data)
+	name := hex.EncodeToString(sum[:]) + "." + ext
+	if exists, err := s.Storage.Exists(KeyPrefix + name); err != nil || exists {
+		return URLPrefix + name, err
+	}
+	if err := s.Storage.Put(KeyPrefix+name, bytes.NewReader(data), "image/"+format); err != nil {
+		return "", err
+	}
+	return URLPrefix + name, nil
+}
+
+// client downloads external images, slow servers don't hold schedule sync
+var client = &http.Client{Timeout: 30 * time.Second}
+
+// Download stores image from url
+func (s *Store) Download(url string) (string, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("image download status %d", resp.StatusCode)
+	}
+	return s.Save(resp.Body)
+}
+
+// Variant returns cached or freshly made variant of original
+func (s *Store) Variant(name string, o Options) ([]byte, string, error) {
+	if !hashName.MatchString(name) {
+		return nil, "", os.ErrNotExist
+	}
+	if err := o.Validate(); err != nil {
+		return nil, "", err
+	}
+	format := "jpeg"
+	if strings.HasSuffix(name, ".png") {
+		format = "png"
+	}
+	cached := filepath.Join(s.CacheDir, strings.TrimSuffix(name, filepath.Ext(name))+"_"+o.Key()+"."+format)
+	if data, err := os.ReadFile(cached); err == nil {
+		return data, "image/" + format, nil
+	}
+
+	f, err := s.Storage.Get(KeyPrefix + name)
+	if err == storage.ErrNotFound {
+		return nil, "", os.ErrNotExist
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+	img, _, err := Decode(f)
+	if err != nil {
+		return nil, "", err
+	}
+	img, err = Process(img, o)
+	if err != nil {
+		return nil, "", err
+	}
+	data, contentType, err := Encode(img, format)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := os.MkdirAll(s.CacheDir, 0755); err != nil {
+		return nil, "", err
+	}
+	if err := writeFile(cached, data); err != nil {
+		return nil, "", err
+	}
+	return data, contentType, nil
+}
+
+// writeFile writes via temp file so readers never see partial image
+func writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// ClearCache removes cached variants of original
+func (s *Store) ClearCache(name string) error {
+	if !hashName.MatchString(name) {
+		return os.ErrNotExist
+	}
+	files, err := filepath.Glob(filepath.Join(s.CacheDir, strings.TrimSuffix(name, filepath.Ext(name))+"_*"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// PurgeCache removes cached variants of originals for which keep returns false,
+// keep gets hash part of original name
+func (s *Store) PurgeCache(keep func(hash string) bool) (int, error) {
+	entries, err := os.ReadDir(s.CacheDir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	var removed int
+	for _, entry := range entries {
+		hash, _, ok := strings.Cut(entry.Name(), "_")
+		if entry.IsDir() || !ok || len(hash) != 64 || keep(hash) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.CacheDir, entry.Name())); err != nil && !os.IsNotExist(err) {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
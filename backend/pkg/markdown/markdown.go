@@ -0,0 +1,138 @@
+// Package markdown renders the small markdown subset used by site pages:
+// headings, paragraphs, lists, quotes, code, rules, emphasis, links and images.
+// Raw html is escaped.
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	heading   = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*$`)
+	unordered = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	ordered   = regexp.MustCompile(`^\s*\d+[.)]\s+(.*)$`)
+	rule      = regexp.MustCompile(`^\s*([-*_])(\s*([-*_])){2,}\s*$`)
+
+	code   = regexp.MustCompile("`([^`]+)`")
+	image  = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)\)`)
+	link   = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	strong = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+	em     = regexp.MustCompile(`\*([^*]+)\*|\b_([^_]+)_\b`)
+)
+
+// Render converts markdown to html
+func Render(src string) string {
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	var b strings.Builder
+	var paragraph []string
+	list := ""
+
+	flush := func() {
+		if len(paragraph) > 0 {
+			b.WriteString("<p>" + inline(strings.Join(paragraph, "\n")) + "</p>\n")
+			paragraph = nil
+		}
+		if list != "" {
+			b.WriteString("</" + list + ">\n")
+			list = ""
+		}
+	}
+	openList := func(tag string) {
+		if list == tag {
+			return
+		}
+		flush()
+		b.WriteString("<" + tag + ">\n")
+		list = tag
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			flush()
+		case strings.HasPrefix(trimmed, "```"):
+			flush()
+			var block []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				block = append(block, lines[i])
+			}
+			b.WriteString("<pre><code>" + html.EscapeString(strings.Join(block, "\n")) + "</code></pre>\n")
+		case heading.MatchString(trimmed):
+			flush()
+			m := heading.FindStringSubmatch(trimmed)
+			level := strconv.Itoa(len(m[1]))
+			b.WriteString("<h" + level + ">" + inline(m[2]) + "</h" + level + ">\n")
+		case rule.MatchString(trimmed):
+			flush()
+			b.WriteString("<hr>\n")
+		case strings.HasPrefix(trimmed, ">"):
+			flush()
+			var quote []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				quote = append(quote, strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(lines[i]), ">"), " "))
+			}
+			i--
+			b.WriteString("<blockquote>\n" + Render(strings.Join(quote, "\n")) + "</blockquote>\n")
+		case unordered.MatchString(line):
+			openList("ul")
+			b.WriteString("<li>" + inline(unordered.FindStringSubmatch(line)[1]) + "</li>\n")
+		case ordered.MatchString(line):
+			openList("ol")
+			b.WriteString("<li>" + inline(ordered.FindStringSubmatch(line)[1]) + "</li>\n")
+		default:
+			if list != "" {
+				flush()
+			}
+			paragraph = append(paragraph, trimmed)
+		}
+	}
+	flush()
+	return b.String()
+}
+
+// inline renders emphasis, code, links and images of escaped text
+func inline(text string) string {
+	text = html.EscapeString(text)
+	var codes []string
+	text = code.ReplaceAllStringFunc(text, func(s string) string {
+		codes = append(codes, "<code>"+code.FindStringSubmatch(s)[1]+"</code>")
+		return "\x00" + strconv.Itoa(len(codes)-1) + "\x00"
+	})
+	text = image.ReplaceAllStringFunc(text, func(s string) string {
+		m := image.FindStringSubmatch(s)
+		if !safeURL(m[2]) {
+			return m[1]
+		}
+		return `<img src="` + m[2] + `" alt="` + m[1] + `">`
+	})
+	text = link.ReplaceAllStringFunc(text, func(s string) string {
+		m := link.FindStringSubmatch(s)
+		if !safeURL(m[2]) {
+			return m[1]
+		}
+		return `<a href="` + m[2] + `">` + m[1] + `</a>`
+	})
+	text = strong.ReplaceAllString(text, "<strong>$1$2</strong>")
+	text = em.ReplaceAllString(text, "<em>$1$2</em>")
+	text = strings.ReplaceAll(text, "\n", "<br>\n")
+	for i, c := range codes {
+		text = strings.Replace(text, "\x00"+strconv.Itoa(i)+"\x00", c, 1)
+	}
+	return text
+}
+
+// safeURL allows web, mail, phone and site relative links
+func safeURL(url string) bool {
+	lower := strings.ToLower(url)
+	for _, prefix := range []string{"http://", "https://", "mailto:", "tel:", "/", "#"} {
+		if strings.HasPrefix(lower, prefix) {
+			return true
+		}
+	}
+	return false
+}
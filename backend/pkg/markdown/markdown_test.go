@@ -0,0 +1,35 @@
+package markdown
+
+import "testing"
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"paragraph", "Привет,\nмир", "<p>Привет,<br>\nмир</p>\n"},
+		{"paragraphs", "один\n\nдва", "<p>один</p>\n<p>два</p>\n"},
+		{"heading", "## Правила ##", "<h2>Правила</h2>\n"},
+		{"rule", "---", "<hr>\n"},
+		{"unordered list", "- один\n* два", "<ul>\n<li>один</li>\n<li>два</li>\n</ul>\n"},
+		{"ordered list", "1. один\n2) два", "<ol>\n<li>один</li>\n<li>два</li>\n</ol>\n"},
+		{"list and paragraph", "- один\nтекст", "<ul>\n<li>один</li>\n</ul>\n<p>текст</p>\n"},
+		{"quote", "> цитата\n> дальше", "<blockquote>\n<p>цитата<br>\nдальше</p>\n</blockquote>\n"},
+		{"code block", "```\n<b>x</b>\n```", "<pre><code>&lt;b&gt;x&lt;/b&gt;</code></pre>\n"},
+		{"emphasis", "**жирный** и *курсив* и _тоже_", "<p><strong>жирный</strong> и <em>курсив</em> и <em>тоже</em></p>\n"},
+		{"inline code", "`**x**`", "<p><code>**x**</code></p>\n"},
+		{"link", "[сайт](https://example.com)", `<p><a href="https://example.com">сайт</a></p>` + "\n"},
+		{"relative link", "[FAQ](/faq)", `<p><a href="/faq">FAQ</a></p>` + "\n"},
+		{"image", "![зал](/uploads/images/a.jpg)", `<p><img src="/uploads/images/a.jpg" alt="зал"></p>` + "\n"},
+		{"unsafe link", "[x](javascript:alert)", "<p>x</p>\n"},
+		{"raw html", "<script>alert(1)</script>", "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>\n"},
+		{"quote in attribute", `[x](/a"onclick=b)`, `<p><a href="/a&#34;onclick=b">x</a></p>` + "\n"},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		if got := Render(tt.src); got != tt.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.name, got, tt.want)
+		}
+	}
+}
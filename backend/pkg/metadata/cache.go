@@ -0,0 +1,78 @@
+package metadata
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cached keeps provider responses on disk, with Refresh cache is not read
+// but fresh responses are still written to it
+type Cached struct {
+	Provider
+	Dir     string
+	TTL     time.Duration
+	Refresh bool
+}
+
+// Search returns cached search results if they are fresh
+func (c *Cached) Search(query string) ([]Candidate, error) {
+	var candidates []Candidate
+	if c.read("search", query, &candidates) {
+		return candidates, nil
+	}
+	candidates, err := c.Provider.Search(query)
+	if err == nil {
+		c.write("search", query, candidates)
+	}
+	return candidates, err
+}
+
+// Get returns cached movie if it is fresh
+func (c *Cached) Get(id string) (Candidate, error) {
+	var candidate Candidate
+	if c.read("get", id, &candidate) {
+		return candidate, nil
+	}
+	candidate, err := c.Provider.Get(id)
+	if err == nil {
+		c.write("get", id, candidate)
+	}
+	return candidate, err
+}
+
+func (c *Cached) path(method, key string) string {
+	sum := sha1.Sum([]byte(c.Provider.Name() + "|" + method + "|" + key))
+	return filepath.Join(c.Dir, c.Provider.Name(), hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *Cached) read(method, key string, target interface{}) bool {
+	if c.Refresh {
+		return false
+	}
+	path := c.path(method, key)
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) > c.TTL {
+		return false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, target) == nil
+}
+
+func (c *Cached) write(method, key string, value interface{}) {
+	path := c.path(method, key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,65 @@
+package metadata
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+)
+
+// File is a local JSON file with array of candidates, it is used for
+// films unknown to online sources and for fixing their data by hand
+type File struct {
+	Path string
+}
+
+func (f *File) Name() string {
+	return "file"
+}
+
+func (f *File) load() ([]Candidate, error) {
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []Candidate
+	if err := json.Unmarshal(data, &candidates); err != nil {
+		return nil, err
+	}
+	for i := range candidates {
+		candidates[i].Source = f.Name()
+	}
+	return candidates, nil
+}
+
+// Search matches query against titles ignoring case
+func (f *File) Search(query string) ([]Candidate, error) {
+	candidates, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+	query = strings.ToLower(strings.TrimSpace(query))
+	var found []Candidate
+	for _, candidate := range candidates {
+		if strings.Contains(strings.ToLower(candidate.Title), query) || strings.Contains(strings.ToLower(candidate.OriginalTitle), query) {
+			found = append(found, candidate)
+		}
+	}
+	if len(found) == 0 {
+		return nil, ErrNotFound
+	}
+	return found, nil
+}
+
+// Get returns candidate by id
+func (f *File) Get(id string) (Candidate, error) {
+	candidates, err := f.load()
+	if err != nil {
+		return Candidate{}, err
+	}
+	for _, candidate := range candidates {
+		if candidate.ID == id {
+			return candidate, nil
+		}
+	}
+	return Candidate{}, ErrNotFound
+}
@@ -0,0 +1,165 @@
+package metadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const kinopoiskURL = "https://kinopoiskapiunofficial.tech/api/"
+
+var client = &http.Client{Timeout: 30 * time.Second}
+
+// Kinopoisk is kinopoiskapiunofficial.tech provider
+type Kinopoisk struct {
+	APIKey string
+}
+
+type kinopoiskFilm struct {
+	FilmID       int64       `json:"filmId"`
+	KinopoiskID  int64       `json:"kinopoiskId"`
+	NameRu       string      `json:"nameRu"`
+	NameEn       string      `json:"nameEn"`
+	NameOriginal string      `json:"nameOriginal"`
+	Year         interface{} `json:"year"`
+	Description  string      `json:"description"`
+	FilmLength   interface{} `json:"filmLength"`
+	PosterURL    string      `json:"posterUrl"`
+	CoverURL     string      `json:"coverUrl"`
+	Countries    []struct {
+		Country string `json:"country"`
+	} `json:"countries"`
+	Genres []struct {
+		Genre string `json:"genre"`
+	} `json:"genres"`
+}
+
+func (k *Kinopoisk) Name() string {
+	return "kinopoisk"
+}
+
+// Search searches films by keyword
+func (k *Kinopoisk) Search(query string) ([]Candidate, error) {
+	var data struct {
+		Films []kinopoiskFilm `json:"films"`
+	}
+	if err := k.get("v2.1/films/search-by-keyword?page=1&keyword="+url.QueryEscape(query), &data); err != nil {
+		return nil, err
+	}
+	if len(data.Films) == 0 {
+		return nil, ErrNotFound
+	}
+	candidates := make([]Candidate, 0, len(data.Films))
+	for _, film := range data.Films {
+		candidates = append(candidates, k.candidate(film))
+	}
+	return candidates, nil
+}
+
+// Get returns film with staff
+func (k *Kinopoisk) Get(id string) (Candidate, error) {
+	var film kinopoiskFilm
+	if err := k.get("v2.2/films/"+url.PathEscape(id), &film); err != nil {
+		return Candidate{}, err
+	}
+	if film.KinopoiskID == 0 {
+		return Candidate{}, ErrNotFound
+	}
+	candidate := k.candidate(film)
+	var staff []struct {
+		NameRu        string `json:"nameRu"`
+		NameEn        string `json:"nameEn"`
+		ProfessionKey string `json:"professionKey"`
+	}
+	if err := k.get("v1/staff?filmId="+url.QueryEscape(id), &staff); err == nil {
+		for _, person := range staff {
+			name := person.NameRu
+			if name == "" {
+				name = person.NameEn
+			}
+			switch person.ProfessionKey {
+			case "DIRECTOR":
+				candidate.Directors = append(candidate.Directors, name)
+			case "ACTOR":
+				if len(candidate.Actors) < 10 {
+					candidate.Actors = append(candidate.Actors, name)
+				}
+			}
+		}
+	}
+	return candidate, nil
+}
+
+func (k *Kinopoisk) candidate(film kinopoiskFilm) Candidate {
+	id := film.KinopoiskID
+	if id == 0 {
+		id = film.FilmID
+	}
+	candidate := Candidate{
+		Source:        k.Name(),
+		ID:            strconv.FormatInt(id, 10),
+		Title:         film.NameRu,
+		OriginalTitle: film.NameOriginal,
+		Year:          fmt.Sprint(film.Year),
+		Description:   film.Description,
+		Poster:        film.PosterURL,
+		Backdrop:      film.CoverURL,
+		Duration:      kinopoiskLength(film.FilmLength),
+	}
+	if candidate.OriginalTitle == "" {
+		candidate.OriginalTitle = film.NameEn
+	}
+	if film.Year == nil {
+		candidate.Year = ""
+	}
+	for _, country := range film.Countries {
+		candidate.Countries = append(candidate.Countries, country.Country)
+	}
+	for _, genre := range film.Genres {
+		candidate.Genres = append(candidate.Genres, genre.Genre)
+	}
+	return candidate
+}
+
+// kinopoiskLength parses minutes, search returns "1:45" and film returns 105
+func kinopoiskLength(length interface{}) int64 {
+	switch v := length.(type) {
+	case float64:
+		return int64(v)
+	case string:
+		parts := strings.Split(v, ":")
+		if len(parts) == 2 {
+			hours, _ := strconv.ParseInt(parts[0], 10, 64)
+			minutes, _ := strconv.ParseInt(parts[1], 10, 64)
+			return hours*60 + minutes
+		}
+		minutes, _ := strconv.ParseInt(v, 10, 64)
+		return minutes
+	}
+	return 0
+}
+
+func (k *Kinopoisk) get(path string, target interface{}) error {
+	req, err := http.NewRequest("GET", kinopoiskURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("X-API-KEY", k.APIKey)
+	req.Header.Add("Content-Type", "application/json")
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("kinopoisk: status %d", res.StatusCode)
+	}
+	return json.NewDecoder(res.Body).Decode(target)
+}
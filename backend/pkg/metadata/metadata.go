@@ -0,0 +1,141 @@
+package metadata
+
+import (
+	"errors"
+	"strings"
+)
+
+type (
+	// Candidate is a movie found by provider
+	Candidate struct {
+		Source        string   `json:"source"`
+		ID            string   `json:"id"`
+		Title         string   `json:"title"`
+		OriginalTitle string   `json:"original_title"`
+		Year          string   `json:"year"`
+		Description   string   `json:"description"`
+		Countries     []string `json:"countries"`
+		Genres        []string `json:"genres"`
+		Actors        []string `json:"actors"`
+		Directors     []string `json:"directors"`
+		Duration      int64    `json:"duration"`
+		Poster        string   `json:"poster"`
+		Backdrop      string   `json:"backdrop"`
+	}
+	// Provider searches movie metadata in external source
+	Provider interface {
+		Name() string
+		Search(query string) ([]Candidate, error)
+		Get(id string) (Candidate, error)
+	}
+)
+
+// Fields which can be merged from several providers
+const (
+	FieldDescription = "description"
+	FieldCountries   = "countries"
+	FieldGenres      = "genres"
+	FieldActors      = "actors"
+	FieldDirectors   = "directors"
+	FieldDuration    = "duration"
+	FieldPoster      = "poster"
+	FieldBackdrop    = "backdrop"
+)
+
+// ErrNotFound is returned when provider has no such movie
+var ErrNotFound = errors.New("no movie found")
+
+// Chain asks providers in order and merges fields by priority
+type Chain struct {
+	Providers []Provider
+	Priority  map[string][]string // field -> provider names, providers order if not set
+}
+
+// Provider returns provider by name
+func (c *Chain) Provider(name string) (Provider, bool) {
+	for _, p := range c.Providers {
+		if p.Name() == name {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// Candidates returns search results of all providers
+func (c *Chain) Candidates(query string) []Candidate {
+	var candidates []Candidate
+	for _, p := range c.Providers {
+		found, err := p.Search(query)
+		if err != nil {
+			continue
+		}
+		candidates = append(candidates, found...)
+	}
+	return candidates
+}
+
+// Fetch takes the best match of every provider and merges them
+func (c *Chain) Fetch(query string) (Candidate, error) {
+	matches := make(map[string]Candidate)
+	for _, p := range c.Providers {
+		found, err := p.Search(query)
+		if err != nil || len(found) == 0 {
+			continue
+		}
+		// search results are short, details are in full record
+		best, err := p.Get(found[0].ID)
+		if err != nil {
+			best = found[0]
+		}
+		matches[p.Name()] = best
+	}
+	if len(matches) == 0 {
+		return Candidate{}, ErrNotFound
+	}
+	return c.Merge(matches), nil
+}
+
+// Merge fills every field from the first provider in field priority which has it
+func (c *Chain) Merge(matches map[string]Candidate) Candidate {
+	var merged Candidate
+	for _, p := range c.Providers {
+		if m, ok := matches[p.Name()]; ok {
+			merged.Source, merged.ID = m.Source, m.ID
+			merged.Title, merged.OriginalTitle, merged.Year = m.Title, m.OriginalTitle, m.Year
+			break
+		}
+	}
+	pick := func(field string, empty func(Candidate) bool) Candidate {
+		for _, name := range c.order(field) {
+			if m, ok := matches[name]; ok && !empty(m) {
+				return m
+			}
+		}
+		return Candidate{}
+	}
+	merged.Description = pick(FieldDescription, func(m Candidate) bool { return m.Description == "" }).Description
+	merged.Countries = pick(FieldCountries, func(m Candidate) bool { return len(m.Countries) == 0 }).Countries
+	merged.Genres = pick(FieldGenres, func(m Candidate) bool { return len(m.Genres) == 0 }).Genres
+	merged.Actors = pick(FieldActors, func(m Candidate) bool { return len(m.Actors) == 0 }).Actors
+	merged.Directors = pick(FieldDirectors, func(m Candidate) bool { return len(m.Directors) == 0 }).Directors
+	merged.Duration = pick(FieldDuration, func(m Candidate) bool { return m.Duration == 0 }).Duration
+	merged.Poster = pick(FieldPoster, func(m Candidate) bool { return m.Poster == "" }).Poster
+	merged.Backdrop = pick(FieldBackdrop, func(m Candidate) bool { return m.Backdrop == "" }).Backdrop
+	return merged
+}
+
+func (c *Chain) order(field string) []string {
+	if names, ok := c.Priority[field]; ok && len(names) > 0 {
+		return names
+	}
+	names := make([]string, 0, len(c.Providers))
+	for _, p := range c.Providers {
+		names = append(names, p.Name())
+	}
+	return names
+}
+
+// Join joins list fields for text columns of movie
+func Join(values []string) string {
+	return strings.Join(values, ", ")
+}
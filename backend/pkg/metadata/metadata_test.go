@@ -0,0 +1,176 @@
+package metadata
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fake is provider with fixed candidates which counts requests
+type fake struct {
+	name     string
+	found    []Candidate
+	err      error
+	searches int
+	gets     int
+}
+
+func (f *fake) Name() string {
+	return f.name
+}
+
+func (f *fake) Search(query string) ([]Candidate, error) {
+	f.searches++
+	if f.err != nil {
+		return nil, f.err
+	}
+	if len(f.found) == 0 {
+		return nil, ErrNotFound
+	}
+	return f.found, nil
+}
+
+func (f *fake) Get(id string) (Candidate, error) {
+	f.gets++
+	if f.err != nil {
+		return Candidate{}, f.err
+	}
+	for _, candidate := range f.found {
+		if candidate.ID == id {
+			return candidate, nil
+		}
+	}
+	return Candidate{}, ErrNotFound
+}
+
+func TestChainFetch(t *testing.T) {
+	kinopoisk := &fake{name: "kinopoisk", found: []Candidate{{Source: "kinopoisk", ID: "1", Title: "Дюна", Description: "kp", Duration: 155}}}
+	tmdb := &fake{name: "tmdb", found: []Candidate{{Source: "tmdb", ID: "2", Title: "Dune", Description: "tmdb", Poster: "tmdb.jpg", Genres: []string{"sci-fi"}}}}
+	down := &fake{name: "down", err: errors.New("timeout")}
+
+	tests := []struct {
+		name      string
+		chain     Chain
+		want      Candidate
+		wantError error
+	}{
+		{
+			name:  "first provider gives identity, empty fields fall back to next",
+			chain: Chain{Providers: []Provider{kinopoisk, tmdb}},
+			want:  Candidate{Source: "kinopoisk", ID: "1", Title: "Дюна", Description: "kp", Duration: 155, Poster: "tmdb.jpg", Genres: []string{"sci-fi"}},
+		},
+		{
+			name:  "failed provider is skipped",
+			chain: Chain{Providers: []Provider{down, tmdb}},
+			want:  tmdb.found[0],
+		},
+		{
+			name:  "field priority overrides providers order",
+			chain: Chain{Providers: []Provider{kinopoisk, tmdb}, Priority: map[string][]string{FieldDescription: {"tmdb", "kinopoisk"}}},
+			want:  Candidate{Source: "kinopoisk", ID: "1", Title: "Дюна", Description: "tmdb", Duration: 155, Poster: "tmdb.jpg", Genres: []string{"sci-fi"}},
+		},
+		{
+			name:      "nothing found",
+			chain:     Chain{Providers: []Provider{down, &fake{name: "empty"}}},
+			wantError: ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.chain.Fetch("Дюна")
+			if err != tt.wantError {
+				t.Fatalf("error %v, want %v", err, tt.wantError)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChainCandidates(t *testing.T) {
+	chain := Chain{Providers: []Provider{
+		&fake{name: "down", err: errors.New("timeout")},
+		&fake{name: "a", found: []Candidate{{ID: "1"}, {ID: "2"}}},
+		&fake{name: "b", found: []Candidate{{ID: "3"}}},
+	}}
+	if got := chain.Candidates("q"); len(got) != 3 || got[2].ID != "3" {
+		t.Fatalf("candidates: %+v", got)
+	}
+	if _, ok := chain.Provider("b"); !ok {
+		t.Fatal("provider b is not found")
+	}
+	if _, ok := chain.Provider("c"); ok {
+		t.Fatal("unknown provider is found")
+	}
+}
+
+func TestCached(t *testing.T) {
+	provider := &fake{name: "tmdb", found: []Candidate{{Source: "tmdb", ID: "2", Title: "Dune"}}}
+	dir := t.TempDir()
+	cached := &Cached{Provider: provider, Dir: dir, TTL: time.Hour}
+
+	for i := 0; i < 2; i++ {
+		if _, err := cached.Search("dune"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := cached.Get("2"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if provider.searches != 1 || provider.gets != 1 {
+		t.Fatalf("cache is not used: %d searches, %d gets", provider.searches, provider.gets)
+	}
+
+	provider.found[0].Title = "Dune: Part One"
+	refresh := &Cached{Provider: provider, Dir: dir, TTL: time.Hour, Refresh: true}
+	if got, _ := refresh.Get("2"); got.Title != "Dune: Part One" || provider.gets != 2 {
+		t.Fatalf("refresh returned cached %+v", got)
+	}
+	// fresh response is written back for next readers
+	if got, _ := cached.Get("2"); got.Title != "Dune: Part One" || provider.gets != 2 {
+		t.Fatalf("refresh didn't update cache: %+v", got)
+	}
+
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(cached.path("get", "2"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	cached.Get("2")
+	if provider.gets != 3 {
+		t.Fatal("expired entry is used")
+	}
+	// errors are not cached
+	provider.err = errors.New("timeout")
+	if _, err := cached.Search("missing"); err == nil {
+		t.Fatal("error is lost")
+	}
+	if _, err := os.Stat(cached.path("search", "missing")); !os.IsNotExist(err) {
+		t.Fatal("error is cached")
+	}
+}
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "movies.json")
+	data := `[{"id": "local-1", "title": "Сказка", "original_title": "The Tale"}, {"id": "local-2", "title": "Другое"}]`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file := &File{Path: path}
+	found, err := file.Search(" the TALE ")
+	if err != nil || len(found) != 1 || found[0].ID != "local-1" || found[0].Source != "file" {
+		t.Fatalf("search: %+v, %v", found, err)
+	}
+	if _, err := file.Search("missing"); err != ErrNotFound {
+		t.Fatalf("search of missing: %v", err)
+	}
+	if got, err := file.Get("local-2"); err != nil || got.Title != "Другое" {
+		t.Fatalf("get: %+v, %v", got, err)
+	}
+	if _, err := file.Get("local-3"); err != ErrNotFound {
+		t.Fatalf("get of missing: %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package metadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	tmdbURL      = "https://api.tmdb.org/3/"
+	tmdbImageURL = "https://image.tmdb.org/t/p/"
+)
+
+// TMDB is themoviedb.org provider
+type TMDB struct {
+	APIKey string
+}
+
+type tmdbMovie struct {
+	ID                  int64  `json:"id"`
+	Title               string `json:"title"`
+	OriginalTitle       string `json:"original_title"`
+	Overview            string `json:"overview"`
+	ReleaseDate         string `json:"release_date"`
+	Runtime             int64  `json:"runtime"`
+	PosterPath          string `json:"poster_path"`
+	BackdropPath        string `json:"backdrop_path"`
+	ProductionCountries []struct {
+		Name string `json:"name"`
+	} `json:"production_countries"`
+	Genres []struct {
+		Name string `json:"name"`
+	} `json:"genres"`
+	Credits struct {
+		Cast []struct {
+			Name string `json:"name"`
+		} `json:"cast"`
+		Crew []struct {
+			Name string `json:"name"`
+			Job  string `json:"job"`
+		} `json:"crew"`
+	} `json:"credits"`
+}
+
+func (t *TMDB) Name() string {
+	return "tmdb"
+}
+
+// Search searches movies by title
+func (t *TMDB) Search(query string) ([]Candidate, error) {
+	var data struct {
+		Results []tmdbMovie `json:"results"`
+	}
+	if err := t.get("search/movie", url.Values{"query": {query}}, &data); err != nil {
+		return nil, err
+	}
+	if len(data.Results) == 0 {
+		return nil, ErrNotFound
+	}
+	candidates := make([]Candidate, 0, len(data.Results))
+	for _, movie := range data.Results {
+		candidates = append(candidates, t.candidate(movie))
+	}
+	return candidates, nil
+}
+
+// Get returns movie with credits
+func (t *TMDB) Get(id string) (Candidate, error) {
+	var movie tmdbMovie
+	if err := t.get("movie/"+url.PathEscape(id), url.Values{"append_to_response": {"credits"}}, &movie); err != nil {
+		return Candidate{}, err
+	}
+	if movie.ID == 0 {
+		return Candidate{}, ErrNotFound
+	}
+	return t.candidate(movie), nil
+}
+
+func (t *TMDB) candidate(movie tmdbMovie) Candidate {
+	candidate := Candidate{
+		Source:        t.Name(),
+		ID:            strconv.FormatInt(movie.ID, 10),
+		Title:         movie.Title,
+		OriginalTitle: movie.OriginalTitle,
+		Description:   movie.Overview,
+		Duration:      movie.Runtime,
+	}
+	if len(movie.ReleaseDate) >= 4 {
+		candidate.Year = movie.ReleaseDate[:4]
+	}
+	if movie.PosterPath != "" {
+		candidate.Poster = tmdbImageURL + "w500" + movie.PosterPath
+	}
+	if movie.BackdropPath != "" {
+		candidate.Backdrop = tmdbImageURL + "original" + movie.BackdropPath
+	}
+	for _, country := range movie.ProductionCountries {
+		candidate.Countries = append(candidate.Countries, country.Name)
+	}
+	for _, genre := range movie.Genres {
+		candidate.Genres = append(candidate.Genres, strings.ToLower(genre.Name))
+	}
+	for i, actor := range movie.Credits.Cast {
+		if i == 10 {
+			break
+		}
+		candidate.Actors = append(candidate.Actors, actor.Name)
+	}
+	for _, member := range movie.Credits.Crew {
+		if member.Job == "Director" {
+			candidate.Directors = append(candidate.Directors, member.Name)
+		}
+	}
+	return candidate
+}
+
+func (t *TMDB) get(path string, params url.Values, target interface{}) error {
+	params.Set("api_key", t.APIKey)
+	params.Set("language", "ru-RU")
+	res, err := client.Get(tmdbURL + path + "?" + params.Encode())
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("tmdb: status %d", res.StatusCode)
+	}
+	return json.NewDecoder(res.Body).Decode(target)
+}
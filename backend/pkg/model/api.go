@@ -0,0 +1,19 @@
+package model
+
+type (
+	// APIError - error envelope of api v2
+	APIError struct {
+		Error APIErrorBody `json:"error"`
+	}
+	// APIErrorBody - code is stable for clients, message is for people,
+	// details are keyed by fields or parameters
+	APIErrorBody struct {
+		Code    string            `json:"code"`
+		Message string            `json:"message"`
+		Details map[string]string `json:"details,omitempty"`
+	}
+	// PaymentURL - payment form of new sale
+	PaymentURL struct {
+		URL string `json:"url"`
+	}
+)
@@ -16,11 +16,16 @@ type (
 	}
 
 	Flags struct {
-		UpdateSchedule bool `json:"updateSchedule"`
-		ShowYamlStruct bool `json:"showYamlStruct"`
-		Migrate        bool `json:"migrate"`
-		DropTable      bool `json:"drop"`
-		AddUser        bool `json:"user"`
+		UpdateSchedule  bool `json:"updateSchedule"`
+		ShowYamlStruct  bool `json:"showYamlStruct"`
+		Migrate         bool `json:"migrate"`
+		DropTable       bool `json:"drop"`
+		AddUser         bool `json:"user"`
+		MigrateUploads  bool `json:"migrateUploads"`
+		UploadsGC       bool `json:"uploadsGC"`
+		UploadsGCDry    bool `json:"uploadsGCDry"`
+		ImportPages     bool `json:"importPages"`
+		MigrateTimeZone bool `json:"migrateTimeZone"`
 	}
 	Token struct {
 		Token string `json:"token"`
@@ -0,0 +1,28 @@
+package model
+
+type (
+	// EmailTemplate - version of email template, the latest version is used
+	EmailTemplate struct {
+		Common
+		Name     string `json:"name" gorm:"index;uniqueIndex:idx_email_template_version"`
+		CinemaID int64  `json:"cinema_id" gorm:"index;uniqueIndex:idx_email_template_version"` // 0 - default for all cinemas
+		Version  int64  `json:"version" gorm:"uniqueIndex:idx_email_template_version"`
+		Subject  string `json:"subject"`
+		Body     string `json:"body"`
+		AuthorID uint   `json:"author_id"`
+	}
+	// EmailTemplateIn - new version of template
+	EmailTemplateIn struct {
+		CinemaID int64  `json:"cinema_id"`
+		Subject  string `json:"subject"`
+		Body     string `json:"body"`
+	}
+	// EmailTemplatePreview - renders template for real sale if Secret is set or for synthetic one,
+	// empty subject and body are taken from current template
+	EmailTemplatePreview struct {
+		CinemaID int64  `json:"cinema_id"` // cinema override to preview, 0 - default template
+		Subject  string `json:"subject"`
+		Body     string `json:"body"`
+		Secret   string `json:"secret"`
+	}
+)
@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+type (
+	// Event - festival screening, lecture or other event created by editor,
+	// tickets are sold through linked booking performances
+	Event struct {
+		Common
+		Title        string        `json:"title"`
+		Kind         string        `json:"kind"` // festival, lecture, screening
+		Description  string        `json:"description"`
+		Poster       string        `json:"poster"`
+		LinkURL      string        `json:"link_url"`
+		StartsAt     time.Time     `json:"starts_at"`
+		EndsAt       time.Time     `json:"ends_at"`
+		MovieID      int64         `json:"movie_id" gorm:"index"` // optional movie of event
+		IsActive     bool          `json:"is_active"`
+		Performances []Performance `json:"performances" gorm:"many2many:event_performances"`
+	}
+	// EventIn - editable fields of event
+	EventIn struct {
+		Title       string    `json:"title"`
+		Kind        string    `json:"kind"`
+		Description string    `json:"description"`
+		Poster      string    `json:"poster"`
+		LinkURL     string    `json:"link_url"`
+		StartsAt    time.Time `json:"starts_at"`
+		EndsAt      time.Time `json:"ends_at"`
+		MovieID     int64     `json:"movie_id"`
+		IsActive    bool      `json:"is_active"`
+	}
+	// EventPerformances - ids of performances linked to event
+	EventPerformances struct {
+		IDs []uint `json:"ids"`
+	}
+)
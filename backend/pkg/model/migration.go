@@ -0,0 +1,8 @@
+package model
+
+// Migration - data migration which was applied, Value keeps its parameter
+type Migration struct {
+	Common
+	Name  string `json:"name" gorm:"uniqueIndex"`
+	Value string `json:"value"`
+}
@@ -14,7 +14,6 @@ type (
 		Description     string `json:"description"`
 		Duration        int64  `json:"duration"`
 		Actors          string `json:"actors"`
-		Youtube         string `json:"youtube"`
 		Country         string `json:"country"`
 		Director        string `json:"director"`
 		Poster          string `json:"poster"`
@@ -36,7 +35,7 @@ type (
 		AddDuration     int64         `json:"add_duration"` // duration for adds
 		Premiere        time.Time     `json:"premiere"`
 		Actors          string        `json:"actors"`
-		Youtube         string        `json:"youtube"`
+		Youtube         string        `json:"-"` // legacy trailer, moved to trailers on migration
 		Country         string        `json:"country"`
 		Director        string        `json:"director"`
 		Poster          string        `json:"poster"`
@@ -45,24 +44,26 @@ type (
 		IsActive        bool          `json:"is_active"`
 		Index           int64         `json:"index"` // index for sort
 		RentCertificate string        `json:"rent_certificate"`
+		MetadataSource  string        `json:"metadata_source"` // provider of chosen match
+		MetadataID      string        `json:"metadata_id"`
+		IsManual        bool          `json:"is_manual" gorm:"not null;default:false"`  // created by editor, not by schedule sync
+		MergedIntoID    uint          `json:"merged_into_id" gorm:"not null;default:0"` // duplicate merged into other movie
+		ParentID        uint          `json:"parent_id" gorm:"not null;default:0"`      // variant of film, performances belong to parent
+		TitleKey        string        `json:"-" gorm:"index;not null;default:''"`       // normalized title without variant markers
+		Variant         string        `json:"variant"`                                  // variant label from booking name, e.g. 3D, субтитры
+		ShowInUpcoming  bool          `json:"show_in_upcoming"`                         // editor can hide announced film from coming soon
+		PresaleOpen     bool          `json:"presale_open"`                             // performances before premiere are on sale
 		Performances    []Performance `json:"performances"`
+		Trailers        []Trailer     `json:"trailers"`
+		GenreList       []Genre       `json:"genre_list" gorm:"many2many:movie_genres"`
+		Tags            []Tag         `json:"tags" gorm:"many2many:movie_tags"`
 	}
-	MovieImage struct {
-		Title  string
-		Poster string
-		Images []string
+	// MovieUpcoming - editor choice to show movie in coming soon
+	MovieUpcoming struct {
+		Show bool `json:"show"`
 	}
-	MovieKinopoisk struct {
-		Films []struct {
-			Title       string `json:"nameRU"`
-			Description string `json:"description"`
-			Countries   []struct {
-				Country string `json:"country"`
-			} `json:"countries"`
-			Poster string `json:"posterUrl"`
-			Images []struct {
-				FilePath string `json:"url"`
-			} `json:"screenshots"`
-		} `json:"films"`
+	// MovieMerge - duplicates to merge into movie
+	MovieMerge struct {
+		IDs []uint `json:"ids"`
 	}
 )
@@ -1,20 +1,46 @@
 package model
 
+import "time"
+
+// Notification types and audiences
+const (
+	NotificationBanner = "banner"
+	NotificationPopup  = "popup"
+	NotificationSlider = "slider"
+
+	AudienceAll      = "all"
+	AudienceMovie    = "movie"    // AudienceID is movie id
+	AudienceCinema   = "cinema"   // AudienceID is booking cinema id
+	AudienceCheckout = "checkout" // booking and payment pages
+)
+
 // Notification - struct contains all info about notification
 type Notification struct {
 	Common
-	Title      string `json:"title"`
-	Text       string `json:"text"`
-	PictureURL string `json:"picture_url"`
-	LinkURL    string `json:"link_url"`
-	IsInSlider bool   `json:"is_in_slider"`
+	Title      string    `json:"title"`
+	Text       string    `json:"text"`
+	PictureURL string    `json:"picture_url"`
+	LinkURL    string    `json:"link_url"`
+	IsInSlider bool      `json:"is_in_slider"`                         // legacy, moved to Type on migration
+	Type       string    `json:"type" gorm:"not null;default:banner"`  // banner, popup, slider
+	Audience   string    `json:"audience" gorm:"not null;default:all"` // all, movie, cinema, checkout
+	AudienceID int64     `json:"audience_id" gorm:"not null;default:0"`
+	Order      int64     `json:"order"`
+	PublishAt  time.Time `json:"publish_at" gorm:"not null;default:CURRENT_TIMESTAMP"`       // shown at once if empty
+	ExpireAt   time.Time `json:"expire_at" gorm:"not null;default:'0001-01-01 00:00:00+00'"` // shown forever if empty
 }
 
 // Notification - struct contains all info about notification
 type NotificationIn struct {
-	Title      string `json:"title"`
-	Text       string `json:"text"`
-	PictureURL string `json:"picture_url"`
-	LinkURL    string `json:"link_url"`
-	IsInSlider bool   `json:"is_in_slider"`
+	Title      string    `json:"title"`
+	Text       string    `json:"text"`
+	PictureURL string    `json:"picture_url"`
+	LinkURL    string    `json:"link_url"`
+	IsInSlider bool      `json:"is_in_slider"`
+	Type       string    `json:"type"`
+	Audience   string    `json:"audience"`
+	AudienceID int64     `json:"audience_id"`
+	Order      int64     `json:"order"`
+	PublishAt  time.Time `json:"publish_at"`
+	ExpireAt   time.Time `json:"expire_at"`
 }
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// Page formats and menu placements
+const (
+	PageHTML     = "html"
+	PageMarkdown = "markdown"
+
+	MenuNone  = ""
+	MenuTop   = "top"
+	MenuPopup = "popup"
+)
+
+type (
+	// Page - site page edited in admin, every save is kept as revision
+	Page struct {
+		Common
+		Slug           string    `json:"slug" gorm:"uniqueIndex"`
+		Title          string    `json:"title"`
+		Body           string    `json:"body"`
+		Format         string    `json:"format"` // html or markdown
+		HTML           string    `json:"html" gorm:"-"`
+		Carousel       Carousels `json:"carousel" gorm:"type:jsonb"`
+		SEOTitle       string    `json:"seo_title"`
+		SEODescription string    `json:"seo_description"`
+		SEOImage       string    `json:"seo_image"`
+		Menu           string    `json:"menu"`       // empty, top or popup
+		MenuTitle      string    `json:"menu_title"` // title is used if empty
+		MenuOrder      int64     `json:"menu_order"`
+		LinkURL        string    `json:"link_url"` // menu item leads to url instead of page
+		IsPublished    bool      `json:"is_published"`
+		Version        int64     `json:"version"`
+	}
+	// PageIn - editable fields of page
+	PageIn struct {
+		Slug           string    `json:"slug"`
+		Title          string    `json:"title"`
+		Body           string    `json:"body"`
+		Format         string    `json:"format"`
+		Carousel       Carousels `json:"carousel"`
+		SEOTitle       string    `json:"seo_title"`
+		SEODescription string    `json:"seo_description"`
+		SEOImage       string    `json:"seo_image"`
+		Menu           string    `json:"menu"`
+		MenuTitle      string    `json:"menu_title"`
+		MenuOrder      int64     `json:"menu_order"`
+		LinkURL        string    `json:"link_url"`
+		IsPublished    bool      `json:"is_published"`
+	}
+	// PageRevision - saved version of page
+	PageRevision struct {
+		Common
+		PageID   uint  `json:"page_id" gorm:"index"`
+		Version  int64 `json:"version"`
+		AuthorID uint  `json:"author_id"`
+		PageIn   `gorm:"embedded"`
+	}
+	// MenuItem - item of site menu made from pages
+	MenuItem struct {
+		Title string `json:"title"`
+		URL   string `json:"url"`
+		Menu  string `json:"menu"`
+	}
+
+	Carousels []Carousel
+)
+
+func (c *Carousels) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(bytes, c)
+}
+
+func (c Carousels) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
@@ -15,7 +15,42 @@ type (
 		MovieID    int64     `json:"movie_id"`
 		ExternalID int64     `json:"external_id"`
 		HallName   string    `json:"hall_name"`
-		Movie      Movie     `json:"movie"`
-		Places     []Place   `json:"places" gorm:"-"`
+		Variant    string    `json:"variant"` // 3D, субтитры, предсеанс... of grouped film
+		PerformanceAttributes
+		AttributesLocked bool    `json:"attributes_locked"` // set by editor, sync keeps attributes
+		SeatsFree        int64   `json:"seats_free"`
+		SeatsTotal       int64   `json:"seats_total"` // 0 until availability is checked
+		Movie            Movie   `json:"movie"`
+		Places           []Place `json:"places" gorm:"-"`
+	}
+	// ScheduleGroup - performances of one hall or one hour in schedule
+	ScheduleGroup struct {
+		Title        string        `json:"title"`
+		Performances []Performance `json:"performances"`
+	}
+	// ScheduleDay - summary of performances of schedule day for calendar
+	ScheduleDay struct {
+		Date          string    `json:"date"`
+		Count         int64     `json:"count"`
+		First         time.Time `json:"first"`
+		Last          time.Time `json:"last"`
+		SeatsFree     int64     `json:"seats_free"`
+		SeatsTotal    int64     `json:"seats_total"`
+		NearlySoldOut bool      `json:"nearly_sold_out" gorm:"-"`
+	}
+	// PerformanceAttributes - session features derived by rules or set by editor
+	PerformanceAttributes struct {
+		Format           string `json:"format"` // 2D, 3D, IMAX, Dolby Atmos, 4DX
+		OriginalLanguage bool   `json:"original_language"`
+		Subtitles        bool   `json:"subtitles"`
+		AudioDescription bool   `json:"audio_description"`
+		Relaxed          bool   `json:"relaxed"` // light and sound are softened
+		Kids             bool   `json:"kids"`
+		LateShow         bool   `json:"late_show"` // age-restricted late session
+	}
+	// PerformanceAttributesIn - editor override, unlocked attributes are derived again on sync
+	PerformanceAttributesIn struct {
+		PerformanceAttributes
+		Locked bool `json:"locked"`
 	}
 )
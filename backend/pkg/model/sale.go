@@ -4,12 +4,12 @@ type (
 	// PreSale contains all info about pre sale
 	PreSale struct {
 		UserID        int64   `json:"user_id"`
-		Email         string  `json:"email"`
-		PerformanceID int64   `json:"performance_id"`
-		Places        []int64 `json:"places"`
+		Email         string  `json:"email" validate:"required,email"`
+		PerformanceID int64   `json:"performance_id" validate:"required"`
+		Places        []int64 `json:"places" validate:"min=1,max=5"`
 		Pushkin       bool    `json:"pushkin"`
-		FIO           string  `json:"fio"`
-		Phone         string  `json:"phone"`
+		FIO           string  `json:"fio" validate:"max=255"`
+		Phone         string  `json:"phone" validate:"max=32"`
 	}
 	// Sale - struct contains all info about sale
 	Sale struct {
@@ -33,6 +33,10 @@ type (
 		Tickets               Tickets     `json:"tickets" gorm:"type:jsonb"`
 		IP                    string      `json:"ip"`
 		EmailSent             bool        `json:"email_sent"`
+		ReminderSent          bool        `json:"reminder_sent"`
+		FollowUpSent          bool        `json:"follow_up_sent"`
+		SMSSent               bool        `json:"sms_sent"`
+		RefundEmailSent       bool        `json:"refund_email_sent" gorm:"not null;default:true"` // existing rows are not mailed, refundSale queues email
 		IsPushkin             bool        `json:"is_pushkin" `
 		TerminalID            string      `json:"terminal_id"`
 		TerminalOwner         string      `json:"terminal_owner"`
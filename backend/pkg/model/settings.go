@@ -55,7 +55,9 @@ type (
 		KinopoiskAPI   string `yaml:"kinopoisk_api"`
 		YoutubeAPIKey  string `yaml:"youtube_api_api"`
 		SecretJWT      string `yaml:"secret_jwt"`
-		TimeZoneOffset int64  `yaml:"time_zone_offset"`
+		TimeZoneOffset int64  `yaml:"time_zone_offset"` // legacy, hours from UTC, used if time_zone is empty
+		TimeZone       string `yaml:"time_zone"`        // IANA name of cinema time zone, e.g. Asia/Omsk
+		DayStart       string `yaml:"day_start"`        // start of cinema day, night performances belong to previous day, 06:00 if empty
 	}
 	BankSettings struct {
 		// YooKassa
@@ -84,16 +86,118 @@ type (
 		User     string `yaml:"user"`
 		Password string `yaml:"password"`
 	}
+	// WalletSettings - Apple Wallet passes, certificates are PEM files
+	WalletSettings struct {
+		PassTypeIdentifier string  `yaml:"pass_type_identifier"`
+		TeamIdentifier     string  `yaml:"team_identifier"`
+		OrganizationName   string  `yaml:"organization_name"`
+		Certificate        string  `yaml:"certificate"`      // pass type certificate
+		Key                string  `yaml:"key"`              // private key of pass type certificate
+		WWDRCertificate    string  `yaml:"wwdr_certificate"` // Apple WWDR intermediate certificate
+		ImagesDir          string  `yaml:"images_dir"`       // icon.png, logo.png and their @2x versions
+		ForegroundColor    string  `yaml:"foreground_color"`
+		BackgroundColor    string  `yaml:"background_color"`
+		LabelColor         string  `yaml:"label_color"`
+		Latitude           float64 `yaml:"latitude"`
+		Longitude          float64 `yaml:"longitude"`
+	}
+	// ReminderSettings - customer emails before and after performance
+	ReminderSettings struct {
+		ReminderEnabled bool   `yaml:"reminder_enabled"`
+		ReminderHours   int64  `yaml:"reminder_hours"` // hours before performance start
+		FollowUpEnabled bool   `yaml:"follow_up_enabled"`
+		FollowUpHours   int64  `yaml:"follow_up_hours"` // hours after performance end
+		RatingURL       string `yaml:"rating_url"`      // sale secret is added as query parameter
+	}
+	// SMSSettings - sms gateway, templates use text/template with sale fields
+	SMSSettings struct {
+		Provider        string `yaml:"provider"` // smsru, file
+		APIKey          string `yaml:"api_key"`
+		From            string `yaml:"from"`
+		FilePath        string `yaml:"file_path"`    // file provider output, log if empty
+		MaxPerHour      int64  `yaml:"max_per_hour"` // messages per phone
+		TicketsTemplate string `yaml:"tickets_template"`
+		RefundTemplate  string `yaml:"refund_template"`
+	}
+	// MetadataSettings - movie metadata providers: kinopoisk, tmdb, file
+	MetadataSettings struct {
+		Providers     []string            `yaml:"providers"` // search order, kinopoisk if empty
+		Priority      map[string][]string `yaml:"priority"`  // field -> providers, e.g. poster: [tmdb, kinopoisk]
+		TMDBAPIKey    string              `yaml:"tmdb_api_key"`
+		File          string              `yaml:"file"` // local JSON file with movies
+		CacheDir      string              `yaml:"cache_dir"`
+		CacheTTLHours int64               `yaml:"cache_ttl_hours"`
+	}
+	// TrailerSettings - trailer sources: youtube, vk, rutube
+	TrailerSettings struct {
+		Sources []string `yaml:"sources"`  // search order, youtube if empty
+		VKToken string   `yaml:"vk_token"` // user access token for video.search
+	}
+	// StorageSettings - uploads storage: local directory or S3-compatible bucket
+	StorageSettings struct {
+		Backend   string `yaml:"backend"`    // local, s3
+		Dir       string `yaml:"dir"`        // local directory, uploads if empty
+		BaseURL   string `yaml:"base_url"`   // local public url prefix, /uploads if empty
+		Endpoint  string `yaml:"endpoint"`   // s3 endpoint, e.g. https://storage.yandexcloud.net
+		Region    string `yaml:"region"`     // us-east-1 if empty
+		Bucket    string `yaml:"bucket"`     // bucket name
+		AccessKey string `yaml:"access_key"` // s3 access key id
+		SecretKey string `yaml:"secret_key"` // s3 secret access key
+		PublicURL string `yaml:"public_url"` // CDN url, endpoint/bucket if empty
+	}
+	// UploadsGCSettings - removal of uploads not referenced by movies, notifications and settings
+	UploadsGCSettings struct {
+		Enabled    bool  `yaml:"enabled"`     // daily job
+		DryRun     bool  `yaml:"dry_run"`     // only report orphans
+		GraceHours int64 `yaml:"grace_hours"` // 168 if empty
+	}
+	// AttributeRule - sets performance attribute when pattern matches field
+	AttributeRule struct {
+		Attribute string `yaml:"attribute"` // format, original_language, subtitles, audio_description, relaxed, kids, late_show
+		Value     string `yaml:"value"`     // format value, e.g. IMAX
+		Field     string `yaml:"field"`     // movie, hall, variant, any if empty
+		Pattern   string `yaml:"pattern"`   // regular expression
+	}
+	// PerformanceSettings - rules of performance attributes, built-in rules if empty
+	PerformanceSettings struct {
+		Rules                []AttributeRule `yaml:"rules"`
+		LateShowHour         int64           `yaml:"late_show_hour"`          // 22 if empty
+		LateShowAge          int64           `yaml:"late_show_age"`           // 18 if empty
+		NearlySoldOutPercent int64           `yaml:"nearly_sold_out_percent"` // free seats share, 10 if empty
+		AvailabilityDays     int64           `yaml:"availability_days"`       // seats are checked for days ahead, 7 if empty
+	}
+	// FeedPartner - aggregator which gets schedule feed with its key
+	FeedPartner struct {
+		Name    string   `yaml:"name"`
+		Key     string   `yaml:"key"`
+		Formats []string `yaml:"formats"` // json, xml, csv, all if empty
+	}
+	// FeedSettings - schedule exports for aggregators
+	FeedSettings struct {
+		Days     int64         `yaml:"days"` // days of schedule in feed, 14 if empty
+		Partners []FeedPartner `yaml:"partners"`
+	}
 	AppSettings struct {
-		CinemaSettings  `yaml:"cinema_settings"`
-		SiteSettings    `yaml:"site_settings"`
-		BanksSettings   []BankSettings `yaml:"banks_settings"`
-		BookingSettings `yaml:"booking_settings"`
-		MailSettings    `yaml:"mail_settings"`
+		CinemaSettings      `yaml:"cinema_settings"`
+		SiteSettings        `yaml:"site_settings"`
+		BanksSettings       []BankSettings `yaml:"banks_settings"`
+		BookingSettings     `yaml:"booking_settings"`
+		MailSettings        `yaml:"mail_settings"`
+		WalletSettings      `yaml:"wallet_settings"`
+		ReminderSettings    `yaml:"reminder_settings"`
+		SMSSettings         `yaml:"sms_settings"`
+		BotSettings         `yaml:"bot_settings"`
+		MetadataSettings    `yaml:"metadata_settings"`
+		TrailerSettings     `yaml:"trailer_settings"`
+		StorageSettings     `yaml:"storage_settings"`
+		UploadsGCSettings   `yaml:"uploads_gc_settings"`
+		PerformanceSettings `yaml:"performance_settings"`
+		FeedSettings        `yaml:"feed_settings"`
 	}
 	BotSettings struct {
 		TelegramBotAPI string  `yaml:"telegram_api"`
-		AdminToken     string  `yaml:"admin_token"`
+		APIURL         string  `yaml:"api_url"`     // Bot API server, Telegram if empty
+		BotAdminToken  string  `yaml:"admin_token"` // named apart from SiteSettings.AdminToken, both are promoted to AppSettings
 		SberURL        string  `yaml:"sber_url"`
 		SiteURL        string  `yaml:"site_url"`
 		AllowedChats   []int64 `yaml:"allowed_chats"`
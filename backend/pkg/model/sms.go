@@ -0,0 +1,13 @@
+package model
+
+// SMSMessage - struct contains sent sms and its delivery status
+type SMSMessage struct {
+	Common
+	SaleID     uint   `json:"sale_id" gorm:"index"`
+	Phone      string `json:"phone" gorm:"index"`
+	Kind       string `json:"kind"` // tickets, refund
+	Text       string `json:"text"`
+	ProviderID string `json:"provider_id"`
+	Status     string `json:"status"`
+	Error      string `json:"error"`
+}
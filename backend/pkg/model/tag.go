@@ -0,0 +1,50 @@
+package model
+
+type (
+	// Tag - label of movies set by editor or by rule
+	Tag struct {
+		Common
+		Slug      string  `json:"slug" gorm:"uniqueIndex"`
+		Name      string  `json:"name"`
+		Rule      string  `json:"rule"`       // empty for manual tags, max_age, pushkin, last_chance, new, genre
+		RuleValue string  `json:"rule_value"` // age, days or genre slug
+		Movies    []Movie `json:"-" gorm:"many2many:movie_tags"`
+	}
+	// TagIn - editable fields of tag
+	TagIn struct {
+		Slug      string `json:"slug"`
+		Name      string `json:"name"`
+		Rule      string `json:"rule"`
+		RuleValue string `json:"rule_value"`
+	}
+	// Genre - normalized genre of movies
+	Genre struct {
+		Common
+		Slug string `json:"slug" gorm:"uniqueIndex"`
+		Name string `json:"name"`
+	}
+	// Collection - shelf of movies on site, movies are chosen by editor or by tag
+	Collection struct {
+		Common
+		Slug        string  `json:"slug" gorm:"uniqueIndex"`
+		Title       string  `json:"title"`
+		Description string  `json:"description"`
+		Order       int64   `json:"order"`
+		IsActive    bool    `json:"is_active"`
+		TagID       uint    `json:"tag_id"` // movies of tag instead of chosen ones
+		Movies      []Movie `json:"movies" gorm:"many2many:collection_movies"`
+	}
+	// CollectionIn - editable fields of collection
+	CollectionIn struct {
+		Slug        string `json:"slug"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Order       int64  `json:"order"`
+		IsActive    bool   `json:"is_active"`
+		TagID       uint   `json:"tag_id"`
+	}
+	// IDs - ids of linked records
+	IDs struct {
+		IDs []uint `json:"ids"`
+	}
+)
@@ -0,0 +1,29 @@
+package model
+
+type (
+	// Trailer - struct contains video of movie
+	Trailer struct {
+		Common
+		MovieID    int64  `json:"movie_id" gorm:"index"`
+		Source     string `json:"source"` // youtube, vk, rutube, upload
+		ExternalID string `json:"external_id"`
+		Title      string `json:"title"`
+		URL        string `json:"url"`
+		EmbedURL   string `json:"embed_url"`
+		Preview    string `json:"preview"`
+		Order      int64  `json:"order"`
+	}
+	// TrailerIn - trailer chosen by editor from candidates
+	TrailerIn struct {
+		Source     string `json:"source"`
+		ExternalID string `json:"external_id"`
+		Title      string `json:"title"`
+		URL        string `json:"url"`
+		EmbedURL   string `json:"embed_url"`
+		Preview    string `json:"preview"`
+	}
+	// TrailerOrder - ids of movie trailers in new order
+	TrailerOrder struct {
+		IDs []uint `json:"ids"`
+	}
+)
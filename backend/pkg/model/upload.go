@@ -0,0 +1,8 @@
+package model
+
+// OrphanedUpload - stored file which is not referenced anywhere, CreatedAt is when it was found
+type OrphanedUpload struct {
+	Common
+	Key  string `json:"key" gorm:"uniqueIndex"`
+	Size int64  `json:"size"`
+}
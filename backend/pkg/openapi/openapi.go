@@ -0,0 +1,274 @@
+// Package openapi builds OpenAPI 3 document from route list, schemas are made
+// from Go types by json and validate tags.
+package openapi
+
+import (
+	"path"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eugenetolok/go-poravkino/pkg/validate"
+)
+
+type (
+	// Document is OpenAPI 3.0 document
+	Document struct {
+		OpenAPI    string              `json:"openapi"`
+		Info       Info                `json:"info"`
+		Servers    []Server            `json:"servers,omitempty"`
+		Paths      map[string]PathItem `json:"paths"`
+		Components Components          `json:"components"`
+	}
+	// Info describes API
+	Info struct {
+		Title   string `json:"title"`
+		Version string `json:"version"`
+	}
+	// Server is base url of API
+	Server struct {
+		URL string `json:"url"`
+	}
+	// Components keeps named schemas
+	Components struct {
+		Schemas map[string]*Schema `json:"schemas"`
+	}
+	// PathItem maps lower case methods to operations
+	PathItem map[string]Operation
+	// Operation is one route
+	Operation struct {
+		Summary     string              `json:"summary,omitempty"`
+		Tags        []string            `json:"tags,omitempty"`
+		Parameters  []Parameter         `json:"parameters,omitempty"`
+		RequestBody *RequestBody        `json:"requestBody,omitempty"`
+		Responses   map[string]Response `json:"responses"`
+	}
+	// Parameter is path or query parameter
+	Parameter struct {
+		Name        string  `json:"name"`
+		In          string  `json:"in"` // path or query
+		Description string  `json:"description,omitempty"`
+		Required    bool    `json:"required,omitempty"`
+		Schema      *Schema `json:"schema"`
+	}
+	// RequestBody is json body of operation
+	RequestBody struct {
+		Required bool                 `json:"required"`
+		Content  map[string]MediaType `json:"content"`
+	}
+	// Response is response of status
+	Response struct {
+		Description string               `json:"description"`
+		Content     map[string]MediaType `json:"content,omitempty"`
+	}
+	// MediaType holds schema of content type
+	MediaType struct {
+		Schema *Schema `json:"schema"`
+	}
+	// Schema is subset of JSON schema used by OpenAPI
+	Schema struct {
+		Ref                  string             `json:"$ref,omitempty"`
+		Type                 string             `json:"type,omitempty"`
+		Format               string             `json:"format,omitempty"`
+		Pattern              string             `json:"pattern,omitempty"`
+		Enum                 []string           `json:"enum,omitempty"`
+		Minimum              *float64           `json:"minimum,omitempty"`
+		Maximum              *float64           `json:"maximum,omitempty"`
+		MinLength            *int64             `json:"minLength,omitempty"`
+		MaxLength            *int64             `json:"maxLength,omitempty"`
+		MinItems             *int64             `json:"minItems,omitempty"`
+		MaxItems             *int64             `json:"maxItems,omitempty"`
+		Items                *Schema            `json:"items,omitempty"`
+		Properties           map[string]*Schema `json:"properties,omitempty"`
+		AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+		Required             []string           `json:"required,omitempty"`
+	}
+	// Route documents one route, Body and Response are samples of their types
+	Route struct {
+		Method   string
+		Path     string // echo form, /movies/:id
+		Summary  string
+		Tag      string
+		Params   []Parameter // query parameters, path ones are added from path
+		Body     interface{}
+		Response interface{}
+	}
+)
+
+var pathParam = regexp.MustCompile(`:([A-Za-z_]+)`)
+
+// Path turns echo path to OpenAPI path
+func Path(path string) string {
+	return pathParam.ReplaceAllString(path, "{$1}")
+}
+
+// PathParams returns parameters of echo path
+func PathParams(path string) []Parameter {
+	var params []Parameter
+	for _, m := range pathParam.FindAllStringSubmatch(path, -1) {
+		schema := &Schema{Type: "string"}
+		if m[1] == "id" {
+			schema = &Schema{Type: "integer", Format: "int64"}
+		}
+		params = append(params, Parameter{Name: m[1], In: "path", Required: true, Schema: schema})
+	}
+	return params
+}
+
+// Build makes document of routes, errorType is a sample of error envelope which every route may answer with
+func Build(info Info, server string, routes []Route, errorType interface{}) Document {
+	doc := Document{
+		OpenAPI:    "3.0.3",
+		Info:       info,
+		Servers:    []Server{{URL: server}},
+		Paths:      map[string]PathItem{},
+		Components: Components{Schemas: map[string]*Schema{}},
+	}
+	errorSchema := SchemaOf(reflect.TypeOf(errorType), doc.Components.Schemas)
+
+	for _, route := range routes {
+		operation := Operation{
+			Summary:    route.Summary,
+			Parameters: append(PathParams(route.Path), route.Params...),
+			Responses: map[string]Response{
+				"default": {Description: "error", Content: jsonContent(errorSchema)},
+			},
+		}
+		if route.Tag != "" {
+			operation.Tags = []string{route.Tag}
+		}
+		if route.Body != nil {
+			operation.RequestBody = &RequestBody{Required: true, Content: jsonContent(SchemaOf(reflect.TypeOf(route.Body), doc.Components.Schemas))}
+		}
+		ok := Response{Description: "success"}
+		if route.Response != nil {
+			ok.Content = jsonContent(SchemaOf(reflect.TypeOf(route.Response), doc.Components.Schemas))
+		}
+		operation.Responses["200"] = ok
+		item := Path(route.Path)
+		if doc.Paths[item] == nil {
+			doc.Paths[item] = PathItem{}
+		}
+		doc.Paths[item][strings.ToLower(route.Method)] = operation
+	}
+	return doc
+}
+
+func jsonContent(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: schema}}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// SchemaOf returns schema of type, named structs are put to components and referenced
+func SchemaOf(t reflect.Type, components map[string]*Schema) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		format := "int32"
+		if t.Bits() == 64 {
+			format = "int64"
+		}
+		return &Schema{Type: "integer", Format: format}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: SchemaOf(t.Elem(), components)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: SchemaOf(t.Elem(), components)}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return structSchema(t, components)
+		}
+		// package keeps names of different packages apart, e.g. model.Movie and extapi.Movie
+		name := path.Base(t.PkgPath()) + "." + t.Name()
+		if _, ok := components[name]; !ok {
+			// placeholder stops recursion of linked models
+			components[name] = &Schema{}
+			*components[name] = *structSchema(t, components)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+	return &Schema{}
+}
+
+func structSchema(t reflect.Type, components map[string]*Schema) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	addFields(schema, t, components)
+	return schema
+}
+
+func addFields(schema *Schema, t reflect.Type, components map[string]*Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			embedded := field.Type
+			for embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				addFields(schema, embedded, components)
+				continue
+			}
+		}
+		name := validate.FieldName(field)
+		if name == "" {
+			continue
+		}
+		property := SchemaOf(field.Type, components)
+		for _, rule := range validate.Rules(field.Tag.Get("validate")) {
+			applyRule(property, rule, &schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+}
+
+// applyRule documents validate rule on property schema
+func applyRule(property *Schema, rule validate.Rule, required *[]string, name string) {
+	switch rule.Name {
+	case "required":
+		*required = append(*required, name)
+	case "email":
+		property.Format = "email"
+	case "oneof":
+		property.Enum = strings.Fields(rule.Value)
+	case "min", "max":
+		value, err := strconv.ParseFloat(rule.Value, 64)
+		if err != nil {
+			return
+		}
+		length := int64(value)
+		switch {
+		case property.Type == "string" && rule.Name == "min":
+			property.MinLength = &length
+		case property.Type == "string":
+			property.MaxLength = &length
+		case property.Type == "array" && rule.Name == "min":
+			property.MinItems = &length
+		case property.Type == "array":
+			property.MaxItems = &length
+		case rule.Name == "min":
+			property.Minimum = &value
+		default:
+			property.Maximum = &value
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package pkpass
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sort"
+)
+
+type (
+	// Pass is the content of pass.json, only the keys we use are described
+	Pass struct {
+		FormatVersion      int         `json:"formatVersion"`
+		PassTypeIdentifier string      `json:"passTypeIdentifier"`
+		SerialNumber       string      `json:"serialNumber"`
+		TeamIdentifier     string      `json:"teamIdentifier"`
+		OrganizationName   string      `json:"organizationName"`
+		Description        string      `json:"description"`
+		LogoText           string      `json:"logoText,omitempty"`
+		ForegroundColor    string      `json:"foregroundColor,omitempty"`
+		BackgroundColor    string      `json:"backgroundColor,omitempty"`
+		LabelColor         string      `json:"labelColor,omitempty"`
+		RelevantDate       string      `json:"relevantDate,omitempty"`
+		ExpirationDate     string      `json:"expirationDate,omitempty"`
+		Voided             bool        `json:"voided,omitempty"`
+		Barcodes           []Barcode   `json:"barcodes,omitempty"`
+		Barcode            *Barcode    `json:"barcode,omitempty"` // legacy key for iOS 8 and older
+		Locations          []Location  `json:"locations,omitempty"`
+		EventTicket        *PassFields `json:"eventTicket,omitempty"`
+	}
+	// Barcode of the pass
+	Barcode struct {
+		Format          string `json:"format"`
+		Message         string `json:"message"`
+		MessageEncoding string `json:"messageEncoding"`
+		AltText         string `json:"altText,omitempty"`
+	}
+	// Location makes pass relevant near the cinema
+	Location struct {
+		Latitude     float64 `json:"latitude"`
+		Longitude    float64 `json:"longitude"`
+		RelevantText string  `json:"relevantText,omitempty"`
+	}
+	// PassFields are groups of fields shown on the front and the back of the pass
+	PassFields struct {
+		HeaderFields    []Field `json:"headerFields,omitempty"`
+		PrimaryFields   []Field `json:"primaryFields,omitempty"`
+		SecondaryFields []Field `json:"secondaryFields,omitempty"`
+		AuxiliaryFields []Field `json:"auxiliaryFields,omitempty"`
+		BackFields      []Field `json:"backFields,omitempty"`
+	}
+	// Field is a single label-value pair
+	Field struct {
+		Key       string      `json:"key"`
+		Label     string      `json:"label,omitempty"`
+		Value     interface{} `json:"value"`
+		DateStyle string      `json:"dateStyle,omitempty"`
+		TimeStyle string      `json:"timeStyle,omitempty"`
+	}
+)
+
+// BarcodeQR is the QR code barcode format
+const BarcodeQR = "PKBarcodeFormatQR"
+
+// Build makes signed .pkpass archive from pass and its images (file name -> png data)
+func Build(pass Pass, images map[string][]byte, signer *Signer) ([]byte, error) {
+	if _, ok := images["icon.png"]; !ok {
+		return nil, errors.New("pkpass: icon.png is required")
+	}
+	if signer == nil {
+		return nil, errors.New("pkpass: signer is not configured")
+	}
+	pass.FormatVersion = 1
+	passJSON, err := json.Marshal(pass)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{"pass.json": passJSON}
+	for name, data := range images {
+		files[name] = data
+	}
+
+	manifest := make(map[string]string, len(files))
+	for name, data := range files {
+		sum := sha1.Sum(data)
+		manifest[name] = hex.EncodeToString(sum[:])
+	}
+	manifestJSON, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := signer.Sign(manifestJSON)
+	if err != nil {
+		return nil, err
+	}
+	files["manifest.json"] = manifestJSON
+	files["signature"] = signature
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf := new(bytes.Buffer)
+	archive := zip.NewWriter(buf)
+	for _, name := range names {
+		w, err := archive.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(files[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
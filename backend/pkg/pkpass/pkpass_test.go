@@ -0,0 +1,255 @@
+package pkpass
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testChain returns self-signed CA and leaf signer issued by it
+func testChain(t *testing.T, leafKey crypto.Signer) (*x509.Certificate, *Signer) {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test WWDR"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, caKey.Public(), caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		Subject:      pkix.Name{CommonName: "Pass Type ID: pass.test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, ca, leafKey.Public(), caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(leafDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ca, &Signer{Certificate: leaf, Key: leafKey, Intermediate: ca}
+}
+
+// verify checks detached signature of data like a PKCS#7 verifier does
+func verify(t *testing.T, ca *x509.Certificate, signature, data []byte) {
+	t.Helper()
+	var outer contentInfo
+	if rest, err := asn1.Unmarshal(signature, &outer); err != nil || len(rest) > 0 {
+		t.Fatalf("content info: %v", err)
+	}
+	if !outer.ContentType.Equal(oidSignedData) {
+		t.Fatalf("content type is %v", outer.ContentType)
+	}
+	var sd signedData
+	if _, err := asn1.Unmarshal(outer.Content.Bytes, &sd); err != nil {
+		t.Fatalf("signed data: %v", err)
+	}
+	if len(sd.ContentInfo.Content.Bytes) != 0 {
+		t.Fatal("signature is not detached")
+	}
+	certificates, err := x509.ParseCertificates(sd.Certificates.Bytes)
+	if err != nil || len(certificates) != 2 {
+		t.Fatalf("certificates: %d, %v", len(certificates), err)
+	}
+	leaf := certificates[0]
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	if _, err := leaf.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}}); err != nil {
+		t.Fatalf("chain: %v", err)
+	}
+
+	var info signerInfo
+	if _, err := asn1.Unmarshal(sd.SignerInfos.Bytes, &info); err != nil {
+		t.Fatalf("signer info: %v", err)
+	}
+	if info.IssuerAndSerialNumber.SerialNumber.Cmp(leaf.SerialNumber) != 0 ||
+		!bytes.Equal(info.IssuerAndSerialNumber.Issuer.FullBytes, leaf.RawIssuer) {
+		t.Fatal("signer info doesn't point to leaf certificate")
+	}
+	var digest []byte
+	for rest := info.AuthenticatedAttributes.Bytes; len(rest) > 0; {
+		var attr attribute
+		var err error
+		if rest, err = asn1.Unmarshal(rest, &attr); err != nil {
+			t.Fatalf("attribute: %v", err)
+		}
+		if attr.Type.Equal(oidMessageDigest) {
+			if _, err := asn1.Unmarshal(attr.Value.Bytes, &digest); err != nil {
+				t.Fatalf("message digest: %v", err)
+			}
+		}
+	}
+	sum := sha256.Sum256(data)
+	if !bytes.Equal(digest, sum[:]) {
+		t.Fatal("message digest doesn't match data")
+	}
+
+	signed, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: info.AuthenticatedAttributes.Bytes})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedSum := sha256.Sum256(signed)
+	switch key := leaf.PublicKey.(type) {
+	case *rsa.PublicKey:
+		err = rsa.VerifyPKCS1v15(key, crypto.SHA256, signedSum[:], info.EncryptedDigest)
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, signedSum[:], info.EncryptedDigest) {
+			err = rsa.ErrVerification
+		}
+	}
+	if err != nil {
+		t.Fatalf("signature: %v", err)
+	}
+}
+
+func TestSign(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, key := range map[string]crypto.Signer{"rsa": rsaKey, "ecdsa": ecKey} {
+		t.Run(name, func(t *testing.T) {
+			ca, signer := testChain(t, key)
+			manifest := []byte(`{"pass.json":"0000"}`)
+			signature, err := signer.Sign(manifest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			verify(t, ca, signature, manifest)
+		})
+	}
+}
+
+func TestLoadSigner(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, signer := testChain(t, key)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	files := map[string]*pem.Block{
+		"cert.pem": {Type: "CERTIFICATE", Bytes: signer.Certificate.Raw},
+		"key.pem":  {Type: "PRIVATE KEY", Bytes: keyDER},
+		"wwdr.pem": {Type: "CERTIFICATE", Bytes: ca.Raw},
+	}
+	for name, block := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), pem.EncodeToMemory(block), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	loaded, err := LoadSigner(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), filepath.Join(dir, "wwdr.pem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := loaded.Sign([]byte("manifest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify(t, ca, signature, []byte("manifest"))
+}
+
+func TestBuild(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, signer := testChain(t, key)
+	images := map[string][]byte{"icon.png": []byte("icon"), "logo.png": []byte("logo")}
+
+	if _, err := Build(Pass{}, map[string][]byte{}, signer); err == nil {
+		t.Fatal("pass without icon is built")
+	}
+	if _, err := Build(Pass{}, images, nil); err == nil {
+		t.Fatal("pass without signer is built")
+	}
+
+	data, err := Build(Pass{SerialNumber: "secret", Barcodes: []Barcode{{Format: BarcodeQR, Message: "secret"}}}, images, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{}
+	for _, file := range archive.File {
+		r, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[file.Name], _ = io.ReadAll(r)
+		r.Close()
+	}
+	for _, name := range []string{"pass.json", "manifest.json", "signature", "icon.png", "logo.png"} {
+		if _, ok := files[name]; !ok {
+			t.Fatalf("%s is missing", name)
+		}
+	}
+
+	var manifest map[string]string
+	if err := json.Unmarshal(files["manifest.json"], &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest) != 3 {
+		t.Fatalf("manifest has %d files, want 3", len(manifest))
+	}
+	for name, hash := range manifest {
+		sum := sha1.Sum(files[name])
+		if hash != hex.EncodeToString(sum[:]) {
+			t.Errorf("hash of %s doesn't match", name)
+		}
+	}
+	var pass Pass
+	if err := json.Unmarshal(files["pass.json"], &pass); err != nil {
+		t.Fatal(err)
+	}
+	if pass.FormatVersion != 1 || pass.SerialNumber != "secret" {
+		t.Fatalf("pass.json is %s", files["pass.json"])
+	}
+	verify(t, ca, files["signature"], files["manifest.json"])
+}
@@ -0,0 +1,227 @@
+package pkpass
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"sort"
+	"time"
+)
+
+var (
+	oidData          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidSigningTime   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 5}
+	oidSHA256        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidRSA           = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidECDSASHA256   = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+)
+
+// Signer makes detached PKCS#7 signatures of pass manifests
+type Signer struct {
+	Certificate  *x509.Certificate
+	Key          crypto.Signer
+	Intermediate *x509.Certificate // Apple WWDR certificate, optional for local testing
+}
+
+type (
+	contentInfo struct {
+		ContentType asn1.ObjectIdentifier
+		Content     asn1.RawValue `asn1:"optional"`
+	}
+	signedData struct {
+		Version          int
+		DigestAlgorithms asn1.RawValue
+		ContentInfo      contentInfo
+		Certificates     asn1.RawValue
+		SignerInfos      asn1.RawValue
+	}
+	signerInfo struct {
+		Version                   int
+		IssuerAndSerialNumber     issuerAndSerial
+		DigestAlgorithm           pkix.AlgorithmIdentifier
+		AuthenticatedAttributes   asn1.RawValue
+		DigestEncryptionAlgorithm pkix.AlgorithmIdentifier
+		EncryptedDigest           []byte
+	}
+	issuerAndSerial struct {
+		Issuer       asn1.RawValue
+		SerialNumber *big.Int
+	}
+	attribute struct {
+		Type  asn1.ObjectIdentifier
+		Value asn1.RawValue
+	}
+)
+
+// LoadSigner reads PEM encoded certificate, private key and WWDR certificate from disk
+func LoadSigner(certFile, keyFile, wwdrFile string) (*Signer, error) {
+	var signer Signer
+	var err error
+	if signer.Certificate, err = readCertificate(certFile); err != nil {
+		return nil, err
+	}
+	if wwdrFile != "" {
+		if signer.Intermediate, err = readCertificate(wwdrFile); err != nil {
+			return nil, err
+		}
+	}
+	keyPEM, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("pkpass: no PEM data in key file")
+	}
+	signer.Key, err = parseKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	return &signer, nil
+}
+
+func readCertificate(filename string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+	return x509.ParseCertificate(data)
+}
+
+func parseKey(der []byte) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, errors.New("pkpass: unsupported private key")
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("pkpass: unsupported private key")
+	}
+	return signer, nil
+}
+
+// Sign returns DER encoded detached PKCS#7 signature of data
+func (s *Signer) Sign(data []byte) ([]byte, error) {
+	var encryptionAlgorithm asn1.ObjectIdentifier
+	switch s.Key.(type) {
+	case *rsa.PrivateKey:
+		encryptionAlgorithm = oidRSA
+	case *ecdsa.PrivateKey:
+		encryptionAlgorithm = oidECDSASHA256
+	default:
+		return nil, errors.New("pkpass: unsupported private key")
+	}
+
+	digest := sha256.Sum256(data)
+	attributes, err := marshalAttributes(
+		attributeValue{oidContentType, oidData},
+		attributeValue{oidSigningTime, time.Now().UTC()},
+		attributeValue{oidMessageDigest, digest[:]},
+	)
+	if err != nil {
+		return nil, err
+	}
+	// signature is calculated over attributes encoded as SET OF, not as [0] IMPLICIT
+	signedAttributes, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: attributes})
+	if err != nil {
+		return nil, err
+	}
+	attributesDigest := sha256.Sum256(signedAttributes)
+	signature, err := s.Key.Sign(rand.Reader, attributesDigest[:], crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+
+	sha256Algorithm := pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue}
+	info := signerInfo{
+		Version: 1,
+		IssuerAndSerialNumber: issuerAndSerial{
+			Issuer:       asn1.RawValue{FullBytes: s.Certificate.RawIssuer},
+			SerialNumber: s.Certificate.SerialNumber,
+		},
+		DigestAlgorithm:           sha256Algorithm,
+		AuthenticatedAttributes:   asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: attributes},
+		DigestEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: encryptionAlgorithm},
+		EncryptedDigest:           signature,
+	}
+	if encryptionAlgorithm.Equal(oidRSA) {
+		info.DigestEncryptionAlgorithm.Parameters = asn1.NullRawValue
+	}
+	infoDER, err := asn1.Marshal(info)
+	if err != nil {
+		return nil, err
+	}
+	algorithmDER, err := asn1.Marshal(sha256Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	certificates := append([]byte{}, s.Certificate.Raw...)
+	if s.Intermediate != nil {
+		certificates = append(certificates, s.Intermediate.Raw...)
+	}
+
+	sd, err := asn1.Marshal(signedData{
+		Version:          1,
+		DigestAlgorithms: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: algorithmDER},
+		ContentInfo:      contentInfo{ContentType: oidData},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: certificates},
+		SignerInfos:      asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: infoDER},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(contentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sd},
+	})
+}
+
+type attributeValue struct {
+	oid   asn1.ObjectIdentifier
+	value interface{}
+}
+
+// marshalAttributes encodes attributes in DER order required for SET OF
+func marshalAttributes(values ...attributeValue) ([]byte, error) {
+	encoded := make([][]byte, 0, len(values))
+	for _, v := range values {
+		valueDER, err := asn1.Marshal(v.value)
+		if err != nil {
+			return nil, err
+		}
+		attr, err := asn1.Marshal(attribute{
+			Type:  v.oid,
+			Value: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: valueDER},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, attr)
+	}
+	sort.Slice(encoded, func(i, j int) bool {
+		return bytes.Compare(encoded[i], encoded[j]) < 0
+	})
+	return bytes.Join(encoded, nil), nil
+}
@@ -0,0 +1,39 @@
+package sms
+
+import (
+	"errors"
+
+	"github.com/eugenetolok/go-poravkino/pkg/model"
+)
+
+// Delivery statuses of messages
+const (
+	StatusQueued    = "queued"
+	StatusSent      = "sent"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+// Provider sends messages through SMS gateway
+type Provider interface {
+	// Send sends text to phone and returns provider message id
+	Send(phone, text string) (string, error)
+	// Status returns delivery status of message by provider id
+	Status(id string) (string, error)
+}
+
+// ErrNotConfigured is returned when provider is not set in settings
+var ErrNotConfigured = errors.New("sms provider is not configured")
+
+// NewProvider returns provider selected in settings
+func NewProvider(s model.SMSSettings) (Provider, error) {
+	switch s.Provider {
+	case "smsru":
+		return &SMSRu{APIKey: s.APIKey, From: s.From}, nil
+	case "file":
+		return &File{Path: s.FilePath}, nil
+	case "":
+		return nil, ErrNotConfigured
+	}
+	return nil, errors.New("unknown sms provider: " + s.Provider)
+}
@@ -0,0 +1,99 @@
+package sms
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const smsRuURL = "https://sms.ru/sms/"
+
+// smsRuClient keeps TLS verification on, params are posted in body so api key,
+// phones and ticket links don't get to urls and logs
+var smsRuClient = &http.Client{Timeout: 15 * time.Second}
+
+// SMSRu is sms.ru gateway
+type SMSRu struct {
+	APIKey string
+	From   string
+}
+
+type smsRuResponse struct {
+	Status     string `json:"status"`
+	StatusCode int    `json:"status_code"`
+	StatusText string `json:"status_text"`
+	SMS        map[string]struct {
+		Status     string `json:"status"`
+		StatusCode int    `json:"status_code"`
+		StatusText string `json:"status_text"`
+		SMSID      string `json:"sms_id"`
+	} `json:"sms"`
+}
+
+// Send sends message, phone is digits only
+func (s *SMSRu) Send(phone, text string) (string, error) {
+	params := url.Values{}
+	params.Set("api_id", s.APIKey)
+	params.Set("to", phone)
+	params.Set("msg", text)
+	params.Set("json", "1")
+	if s.From != "" {
+		params.Set("from", s.From)
+	}
+	var response smsRuResponse
+	if err := smsRuPost("send", params, &response); err != nil {
+		return "", err
+	}
+	if response.Status != "OK" {
+		return "", fmt.Errorf("sms.ru: %d %s", response.StatusCode, response.StatusText)
+	}
+	message, ok := response.SMS[phone]
+	if !ok {
+		return "", errors.New("sms.ru: no message in response")
+	}
+	if message.Status != "OK" {
+		return "", fmt.Errorf("sms.ru: %d %s", message.StatusCode, message.StatusText)
+	}
+	return message.SMSID, nil
+}
+
+// Status checks delivery status of message
+func (s *SMSRu) Status(id string) (string, error) {
+	params := url.Values{}
+	params.Set("api_id", s.APIKey)
+	params.Set("sms_id", id)
+	params.Set("json", "1")
+	var response smsRuResponse
+	if err := smsRuPost("status", params, &response); err != nil {
+		return "", err
+	}
+	message, ok := response.SMS[id]
+	if response.Status != "OK" || !ok {
+		return "", fmt.Errorf("sms.ru: %d %s", response.StatusCode, response.StatusText)
+	}
+	switch message.StatusCode {
+	case 100, 101:
+		return StatusQueued, nil
+	case 102:
+		return StatusSent, nil
+	case 103:
+		return StatusDelivered, nil
+	}
+	return StatusFailed, nil
+}
+
+// smsRuPost posts form params to method and decodes JSON response
+func smsRuPost(method string, params url.Values, target interface{}) error {
+	r, err := smsRuClient.PostForm(smsRuURL+method, params)
+	if err != nil {
+		return fmt.Errorf("sms.ru: %s request failed", method)
+	}
+	defer r.Body.Close()
+	if r.StatusCode != http.StatusOK {
+		return fmt.Errorf("sms.ru: %s returned %s", method, r.Status)
+	}
+	return json.NewDecoder(r.Body).Decode(target)
+}
@@ -1,18 +1,15 @@
 package smtp
 
 import (
-	"bytes"
 	"embed"
-	"fmt"
+	"io"
 	"log"
-	"strings"
-	"text/template"
 
 	"github.com/eugenetolok/go-poravkino/pkg/model"
 	"gopkg.in/gomail.v2"
 )
 
-//go:embed template.htm
+//go:embed *.htm
 var templateFS embed.FS
 
 var mailSettings model.MailSettings
@@ -21,38 +18,38 @@ func InitConfig(m model.MailSettings) {
 	mailSettings = m
 }
 
-// SendTickets sends tickets to user
-func SendTickets(sale model.Sale, domain string) bool {
-	data, err := templateFS.ReadFile("template.htm")
-	if err != nil {
-		log.Print("template reading error: ", err)
-		return false
-	}
-
-	// Create a template and parse the HTML
-	t, err := template.New("").Parse(string(data))
-	if err != nil {
-		log.Print("template parsing error: ", err)
-		return false
-	}
-	buf := new(bytes.Buffer)
-	err = t.Execute(buf, sale)
-	if err != nil { // if there is an error
-		log.Print("template executing error: ", err)
-	}
+// Attachment is a file attached to email
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
 
+// Send sends html email
+func Send(to, subject, body string, attachments ...Attachment) bool {
 	m := gomail.NewMessage()
 	m.SetHeader("From", mailSettings.From)
-	m.SetHeader("To", sale.Email)
-	m.SetHeader("Subject", fmt.Sprintf("Билеты: %d-%s", sale.ExternalID, sale.Secret))
-	m.SetBody("text/html", strings.ReplaceAll(buf.String(), "APP_DOMAIN", domain))
+	m.SetHeader("To", to)
+	m.SetHeader("Subject", subject)
+	m.SetBody("text/html", body)
+	for _, attachment := range attachments {
+		attach(m, attachment)
+	}
 
-	// Send the email to Bob
 	d := gomail.NewDialer(mailSettings.SMTP, mailSettings.Port, mailSettings.User, mailSettings.Password)
 	if err := d.DialAndSend(m); err != nil {
-		log.Println("email didn't send to: " + sale.Email)
+		log.Println("email didn't send to: " + to)
 		log.Println("email err: ", err)
 		return false
 	}
 	return true
 }
+
+func attach(m *gomail.Message, attachment Attachment) {
+	m.Attach(attachment.Name,
+		gomail.SetHeader(map[string][]string{"Content-Type": {attachment.ContentType}}),
+		gomail.SetCopyFunc(func(w io.Writer) error {
+			_, err := w.Write(attachment.Data)
+			return err
+		}))
+}
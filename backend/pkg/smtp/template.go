@@ -0,0 +1,190 @@
+package smtp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"text/template"
+	"time"
+
+	"github.com/eugenetolok/go-poravkino/pkg/model"
+)
+
+// Template names
+const (
+	TemplateTickets  = "tickets"
+	TemplateRefund   = "refund"
+	TemplateReminder = "reminder"
+	TemplateFollowUp = "followup"
+)
+
+// defaults are built-in templates used when there is no template in database
+var defaults = map[string]struct {
+	file    string
+	subject string
+}{
+	TemplateTickets:  {"template.htm", "Билеты: {{ .Sale.Code }}"},
+	TemplateRefund:   {"refund.htm", "Возврат: {{ .Sale.Code }}"},
+	TemplateReminder: {"reminder.htm", "Напоминание о сеансе: {{ .Movie.Name }}"},
+	TemplateFollowUp: {"followup.htm", "Как вам фильм «{{ .Movie.Name }}»?"},
+}
+
+// ErrUnknownTemplate is returned for names without built-in template
+var ErrUnknownTemplate = errors.New("unknown email template")
+
+type (
+	// TemplateData is everything templates can use, it doesn't expose payment details
+	TemplateData struct {
+		Cinema      CinemaData
+		Sale        SaleData
+		Movie       MovieData
+		Performance PerformanceData
+		Links       LinksData
+	}
+	CinemaData struct {
+		Name    string
+		Address string
+		Domain  string
+		Support string
+	}
+	SaleData struct {
+		Code     string // external id and secret, shown to buyer and support
+		Secret   string
+		Amount   int64
+		Email    string
+		Refunded bool
+		Tickets  []TicketData
+	}
+	TicketData struct {
+		Row   string
+		Seat  string
+		Price int64
+	}
+	MovieData struct {
+		Name     string
+		Age      int64
+		Duration int64
+		Poster   string
+	}
+	PerformanceData struct {
+		Date     string
+		Time     string
+		DateTime string
+		Hall     string
+		ThreeD   bool
+	}
+	// LinksData are absolute links, empty link means feature is disabled
+	LinksData struct {
+		Site     string
+		Tickets  string
+		QR       string
+		Wallet   string
+		Calendar string
+		Rating   string
+	}
+)
+
+// Names returns names of all templates
+func Names() []string {
+	return []string{TemplateTickets, TemplateRefund, TemplateReminder, TemplateFollowUp}
+}
+
+// DefaultTemplate returns built-in subject and body of template
+func DefaultTemplate(name string) (string, string, error) {
+	d, ok := defaults[name]
+	if !ok {
+		return "", "", ErrUnknownTemplate
+	}
+	body, err := templateFS.ReadFile(d.file)
+	if err != nil {
+		return "", "", err
+	}
+	return d.subject, string(body), nil
+}
+
+// Render executes subject as text and body as escaped html template
+func Render(subject, body string, data TemplateData) (string, string, error) {
+	subjectTemplate, err := template.New("subject").Option("missingkey=error").Parse(subject)
+	if err != nil {
+		return "", "", err
+	}
+	bodyTemplate, err := htmltemplate.New("body").Option("missingkey=error").Parse(body)
+	if err != nil {
+		return "", "", err
+	}
+	subjectBuf := new(bytes.Buffer)
+	if err := subjectTemplate.Execute(subjectBuf, data); err != nil {
+		return "", "", err
+	}
+	bodyBuf := new(bytes.Buffer)
+	if err := bodyTemplate.Execute(bodyBuf, data); err != nil {
+		return "", "", err
+	}
+	return subjectBuf.String(), bodyBuf.String(), nil
+}
+
+// NewTemplateData converts sale with preloaded performance and movie to template data
+func NewTemplateData(sale model.Sale, cinema model.CinemaSettings) TemplateData {
+	site := "https://" + cinema.DomainName
+	movieName := sale.Performance.Movie.NameSecondary
+	if movieName == "" {
+		movieName = sale.Performance.Movie.Name
+	}
+	data := TemplateData{
+		Cinema: CinemaData{
+			Name:    cinema.CinemaName,
+			Address: cinema.Address,
+			Domain:  cinema.DomainName,
+			Support: cinema.Support,
+		},
+		Sale: SaleData{
+			Code:     fmt.Sprintf("%d-%s", sale.ExternalID, sale.Secret),
+			Secret:   sale.Secret,
+			Amount:   sale.Amount,
+			Email:    sale.Email,
+			Refunded: sale.Refund,
+		},
+		Movie: MovieData{
+			Name:     movieName,
+			Age:      sale.Performance.Movie.Age,
+			Duration: sale.Performance.Movie.Duration,
+		},
+		Performance: PerformanceData{
+			Date:     sale.Performance.Time.Format("02.01.2006"),
+			Time:     sale.Performance.Time.Format("15:04"),
+			DateTime: sale.Performance.Time.Format("02.01.2006 15:04"),
+			Hall:     sale.Performance.HallName,
+			ThreeD:   sale.Performance.ThreeD,
+		},
+		Links: LinksData{
+			Site:     site + "/",
+			Tickets:  site + "/api/sales/code/lost?secret=" + sale.Secret,
+			QR:       site + "/api/qr?secret=" + sale.Secret,
+			Calendar: site + "/api/sales/code/ics?secret=" + sale.Secret,
+		},
+	}
+	if sale.Performance.Movie.Poster != "" {
+		data.Movie.Poster = site + sale.Performance.Movie.Poster
+	}
+	for _, ticket := range sale.Tickets {
+		data.Sale.Tickets = append(data.Sale.Tickets, TicketData{Row: ticket.Row, Seat: ticket.Seat, Price: ticket.Price})
+	}
+	return data
+}
+
+// SampleData returns synthetic sale for previews
+func SampleData(cinema model.CinemaSettings) TemplateData {
+	var sale model.Sale
+	sale.ExternalID = 123456
+	sale.Secret = "0123456789"
+	sale.Email = "buyer@example.com"
+	sale.Amount = 700
+	sale.Tickets = model.Tickets{{Row: "5", Seat: "7", Price: 350}, {Row: "5", Seat: "8", Price: 350}}
+	sale.Performance.Time = time.Now().Add(24 * time.Hour).Truncate(time.Hour)
+	sale.Performance.HallName = "Зал 1"
+	sale.Performance.Movie.NameSecondary = "Пример фильма"
+	sale.Performance.Movie.Age = 12
+	sale.Performance.Movie.Duration = 120
+	return NewTemplateData(sale, cinema)
+}
@@ -0,0 +1,288 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3 keeps objects in S3-compatible bucket (AWS, MinIO, Yandex Object Storage),
+// requests use path-style urls and Signature Version 4
+type S3 struct {
+	Endpoint  string // e.g. https://storage.yandexcloud.net
+	Region    string // us-east-1 if empty
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PublicURL string // CDN or bucket website, endpoint/bucket if empty
+	Client    *http.Client
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// unsignedPayload is sent instead of body hash, so big uploads are streamed
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// Put uploads object, seekable readers (files, uploads) are streamed with known length,
+// other readers are read into memory
+func (s *S3) Put(key string, r io.Reader, contentType string) error {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	var resp *http.Response
+	var err error
+	if seeker, ok := r.(io.Seeker); ok {
+		var start, end int64
+		if start, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			return err
+		}
+		if end, err = seeker.Seek(0, io.SeekEnd); err != nil {
+			return err
+		}
+		if _, err = seeker.Seek(start, io.SeekStart); err != nil {
+			return err
+		}
+		resp, err = s.send(http.MethodPut, key, nil, r, end-start, unsignedPayload, header)
+	} else {
+		var data []byte
+		if data, err = io.ReadAll(r); err != nil {
+			return err
+		}
+		resp, err = s.do(http.MethodPut, key, nil, data, header)
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Get downloads object
+func (s *S3) Get(key string) (io.ReadCloser, error) {
+	resp, err := s.do(http.MethodGet, key, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Exists sends HEAD request
+func (s *S3) Exists(key string) (bool, error) {
+	resp, err := s.do(http.MethodHead, key, nil, nil, nil)
+	if err == ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	return true, nil
+}
+
+// Delete removes object, S3 does not fail on missing keys
+func (s *S3) Delete(key string) error {
+	resp, err := s.do(http.MethodDelete, key, nil, nil, nil)
+	if err == ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// List pages through ListObjectsV2
+func (s *S3) List(prefix string) ([]Object, error) {
+	var objects []Object
+	query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+	for {
+		resp, err := s.do(http.MethodGet, "", query, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range result.Contents {
+			objects = append(objects, Object{Key: c.Key, Size: c.Size, ModTime: c.LastModified})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		query.Set("continuation-token", result.NextContinuationToken)
+	}
+}
+
+// URL returns public url of object
+func (s *S3) URL(key string) string {
+	base := s.PublicURL
+	if base == "" {
+		base = strings.TrimRight(s.Endpoint, "/") + "/" + s.Bucket
+	}
+	return strings.TrimRight(base, "/") + "/" + escapePath(key)
+}
+
+// do sends signed request with body in memory
+func (s *S3) do(method, key string, query url.Values, body []byte, header http.Header) (*http.Response, error) {
+	return s.send(method, key, query, bytes.NewReader(body), int64(len(body)), sha256Hex(body), header)
+}
+
+// send sends signed request of size bytes, payloadHash is body hash or unsignedPayload,
+// non 2xx statuses are errors
+func (s *S3) send(method, key string, query url.Values, body io.Reader, size int64, payloadHash string, header http.Header) (*http.Response, error) {
+	if key != "" {
+		var err error
+		if key, err = CleanKey(key); err != nil {
+			return nil, err
+		}
+	}
+	endpoint, err := url.Parse(strings.TrimRight(s.Endpoint, "/"))
+	if err != nil {
+		return nil, err
+	}
+	uri := endpoint.Path + "/" + s.Bucket
+	if key != "" {
+		uri += "/" + key
+	}
+	rawQuery := canonicalQuery(query)
+	u := *endpoint
+	u.Path = uri
+	u.RawPath = escapePath(uri)
+	u.RawQuery = rawQuery
+
+	req, err := http.NewRequest(method, u.String(), io.NopCloser(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	s.sign(req, escapePath(uri), rawQuery, payloadHash, time.Now().UTC())
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("s3 %s %s: %s %s", method, key, resp.Status, msg)
+	}
+	return resp, nil
+}
+
+// sign adds AWS Signature Version 4 headers
+func (s *S3) sign(req *http.Request, uri, query, payloadHash string, t time.Time) {
+	region := s.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		uri,
+		query,
+		"host:" + req.URL.Host + "\n" +
+			"x-amz-content-sha256:" + payloadHash + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKey, scope, signedHeaders, signature))
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, escape(k)+"="+escape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// escape encodes everything except unreserved characters as SigV4 requires
+func escape(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func escapePath(p string) string {
+	parts := strings.Split(p, "/")
+	for i, part := range parts {
+		parts[i] = escape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
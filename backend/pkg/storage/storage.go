@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/eugenetolok/go-poravkino/pkg/model"
+)
+
+// Object is a stored file, key is a slash separated path like images/<hash>.jpg
+type Object struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// Storage keeps uploads, keys are the same for all backends
+type Storage interface {
+	Put(key string, r io.Reader, contentType string) error
+	Get(key string) (io.ReadCloser, error)
+	Exists(key string) (bool, error)
+	Delete(key string) error
+	// List returns objects with key prefix, empty prefix means all
+	List(prefix string) ([]Object, error)
+	// URL returns public url of object
+	URL(key string) string
+}
+
+// ErrNotFound is returned by Get for missing keys
+var ErrNotFound = errors.New("object not found")
+
+// New returns backend selected in settings, local is default
+func New(s model.StorageSettings, workDir string) (Storage, error) {
+	switch s.Backend {
+	case "", "local":
+		dir := s.Dir
+		if dir == "" {
+			dir = "uploads"
+		}
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(workDir, dir)
+		}
+		baseURL := s.BaseURL
+		if baseURL == "" {
+			baseURL = "/uploads"
+		}
+		return &Local{Dir: dir, BaseURL: baseURL}, nil
+	case "s3":
+		return &S3{
+			Endpoint:  s.Endpoint,
+			Region:    s.Region,
+			Bucket:    s.Bucket,
+			AccessKey: s.AccessKey,
+			SecretKey: s.SecretKey,
+			PublicURL: s.PublicURL,
+		}, nil
+	}
+	return nil, errors.New("unknown storage backend: " + s.Backend)
+}
+
+// CleanKey rejects keys escaping storage root
+func CleanKey(key string) (string, error) {
+	key = path.Clean("/" + key)[1:]
+	if key == "" || strings.HasPrefix(key, ".") {
+		return "", errors.New("wrong storage key")
+	}
+	return key, nil
+}
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Stub is a local S3 stand-in for one bucket, it keeps objects in memory
+// and supports path-style PUT, GET, HEAD, DELETE and ListObjectsV2.
+// If AccessKey is set, Signature Version 4 of requests is checked.
+type Stub struct {
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	Region    string
+	mu        sync.Mutex
+	objects   map[string]stubObject
+}
+
+type stubObject struct {
+	data        []byte
+	contentType string
+	modTime     time.Time
+}
+
+// NewStub returns empty bucket, serve it with http.Server or httptest
+func NewStub(bucket string) *Stub {
+	return &Stub{Bucket: bucket, objects: map[string]stubObject{}}
+}
+
+// ServeHTTP handles requests signed by S3 backend
+func (s *Stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+		http.Error(w, "AccessDenied", http.StatusForbidden)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if s.AccessKey != "" && !s.signed(r, body) {
+		http.Error(w, "SignatureDoesNotMatch", http.StatusForbidden)
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	if path != s.Bucket && !strings.HasPrefix(path, s.Bucket+"/") {
+		http.Error(w, "NoSuchBucket", http.StatusNotFound)
+		return
+	}
+	key := strings.TrimPrefix(strings.TrimPrefix(path, s.Bucket), "/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if key == "" && r.Method == http.MethodGet {
+		s.list(w, r.URL.Query().Get("prefix"))
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		s.objects[key] = stubObject{data: body, contentType: r.Header.Get("Content-Type"), modTime: time.Now().UTC()}
+	case http.MethodGet, http.MethodHead:
+		object, ok := s.objects[key]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", object.contentType)
+		if r.Method == http.MethodGet {
+			w.Write(object.data)
+		}
+	case http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "MethodNotAllowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// signed checks request signature and payload hash like S3 does
+func (s *Stub) signed(r *http.Request, body []byte) bool {
+	payloadHash := r.Header.Get("X-Amz-Content-Sha256")
+	if payloadHash != unsignedPayload && payloadHash != sha256Hex(body) {
+		return false
+	}
+	t, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+	if err != nil {
+		return false
+	}
+	u := *r.URL
+	u.Host = r.Host
+	check := &http.Request{Method: r.Method, URL: &u, Header: http.Header{}}
+	signer := S3{AccessKey: s.AccessKey, SecretKey: s.SecretKey, Region: s.Region}
+	signer.sign(check, r.URL.EscapedPath(), r.URL.RawQuery, payloadHash, t)
+	return check.Header.Get("Authorization") == r.Header.Get("Authorization")
+}
+
+func (s *Stub) list(w http.ResponseWriter, prefix string) {
+	var result listBucketResult
+	keys := make([]string, 0, len(s.objects))
+	for key := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		object := s.objects[key]
+		result.Contents = append(result.Contents, struct {
+			Key          string    `xml:"Key"`
+			Size         int64     `xml:"Size"`
+			LastModified time.Time `xml:"LastModified"`
+		}{key, int64(len(object.data)), object.modTime})
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(struct {
+		XMLName xml.Name `xml:"ListBucketResult"`
+		listBucketResult
+	}{listBucketResult: result})
+}
@@ -0,0 +1,76 @@
+package telegram
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Stub is a local Bot API stand-in, it keeps sent messages and
+// returns pushed updates, so bot can be run without Telegram
+type Stub struct {
+	mu      sync.Mutex
+	lastID  int64
+	updates []Update
+	sent    []Message
+}
+
+// NewStub returns empty stand-in, serve it with http.Server or httptest
+func NewStub() *Stub {
+	return &Stub{}
+}
+
+// Push queues incoming message from chat
+func (s *Stub) Push(chatID int64, text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastID++
+	s.updates = append(s.updates, Update{
+		UpdateID: s.lastID,
+		Message:  &Message{MessageID: s.lastID, Chat: Chat{ID: chatID}, Text: text},
+	})
+}
+
+// Sent returns messages sent by bot
+func (s *Stub) Sent() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.sent...)
+}
+
+// ServeHTTP implements sendMessage and getUpdates methods
+func (s *Stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		ChatID int64  `json:"chat_id"`
+		Text   string `json:"text"`
+		Offset int64  `json:"offset"`
+	}
+	json.NewDecoder(r.Body).Decode(&params)
+	w.Header().Set("Content-Type", "application/json")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/sendMessage"):
+		s.lastID++
+		message := Message{MessageID: s.lastID, Chat: Chat{ID: params.ChatID}, Text: params.Text}
+		s.sent = append(s.sent, message)
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": message})
+	case strings.HasSuffix(r.URL.Path, "/getUpdates"):
+		var pending []Update
+		for _, update := range s.updates {
+			if update.UpdateID >= params.Offset {
+				pending = append(pending, update)
+			}
+		}
+		s.updates = pending
+		if pending == nil {
+			pending = []Update{}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": pending})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": false, "description": "Not Found: method not found"})
+	}
+}
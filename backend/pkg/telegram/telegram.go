@@ -0,0 +1,100 @@
+package telegram
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL is Telegram Bot API server
+const DefaultAPIURL = "https://api.telegram.org"
+
+type (
+	// Bot is Telegram Bot API client
+	Bot struct {
+		Token  string
+		APIURL string // Bot API server, local stand-in can be used instead of Telegram
+		client *http.Client
+	}
+	// Update is an incoming update, only messages are used
+	Update struct {
+		UpdateID int64    `json:"update_id"`
+		Message  *Message `json:"message"`
+	}
+	// Message is a chat message
+	Message struct {
+		MessageID int64  `json:"message_id"`
+		Chat      Chat   `json:"chat"`
+		Text      string `json:"text"`
+	}
+	// Chat where message was sent
+	Chat struct {
+		ID int64 `json:"id"`
+	}
+	response struct {
+		OK          bool            `json:"ok"`
+		Description string          `json:"description"`
+		Result      json.RawMessage `json:"result"`
+	}
+)
+
+// NewBot returns bot client, empty apiURL means Telegram server
+func NewBot(token, apiURL string) *Bot {
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	return &Bot{
+		Token:  token,
+		APIURL: strings.TrimRight(apiURL, "/"),
+		client: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// SendMessage sends text to chat
+func (b *Bot) SendMessage(chatID int64, text string) error {
+	return b.call("sendMessage", map[string]interface{}{
+		"chat_id":                  chatID,
+		"text":                     text,
+		"disable_web_page_preview": true,
+	}, nil)
+}
+
+// GetUpdates long polls new updates starting from offset
+func (b *Bot) GetUpdates(offset int64, timeout int) ([]Update, error) {
+	var updates []Update
+	err := b.call("getUpdates", map[string]interface{}{
+		"offset":          offset,
+		"timeout":         timeout,
+		"allowed_updates": []string{"message"},
+	}, &updates)
+	return updates, err
+}
+
+func (b *Bot) call(method string, params map[string]interface{}, result interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	endpoint := fmt.Sprintf("%s/bot%s/%s", b.APIURL, url.PathEscape(b.Token), method)
+	resp, err := b.client.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	var r response
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return err
+	}
+	if !r.OK {
+		return errors.New("telegram: " + r.Description)
+	}
+	if result != nil {
+		return json.Unmarshal(r.Result, result)
+	}
+	return nil
+}
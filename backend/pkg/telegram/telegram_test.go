@@ -0,0 +1,45 @@
+package telegram
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBotWithStub(t *testing.T) {
+	stub := NewStub()
+	server := httptest.NewServer(stub)
+	defer server.Close()
+	bot := NewBot("123:token", server.URL+"/")
+
+	updates, err := bot.GetUpdates(0, 0)
+	if err != nil || len(updates) != 0 {
+		t.Fatalf("updates of empty stub: %v, %v", updates, err)
+	}
+
+	stub.Push(10, "/today")
+	stub.Push(20, "/sale 1-abc")
+	updates, err = bot.GetUpdates(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updates) != 2 || updates[0].Message.Text != "/today" || updates[1].Message.Chat.ID != 20 {
+		t.Fatalf("updates: %+v", updates)
+	}
+	// confirmed updates are not returned again
+	updates, err = bot.GetUpdates(updates[1].UpdateID+1, 0)
+	if err != nil || len(updates) != 0 {
+		t.Fatalf("confirmed updates are returned: %+v, %v", updates, err)
+	}
+
+	if err := bot.SendMessage(10, "hello"); err != nil {
+		t.Fatal(err)
+	}
+	sent := stub.Sent()
+	if len(sent) != 1 || sent[0].Chat.ID != 10 || sent[0].Text != "hello" {
+		t.Fatalf("sent: %+v", sent)
+	}
+
+	if err := bot.call("unknownMethod", nil, nil); err == nil {
+		t.Fatal("unknown method doesn't fail")
+	}
+}
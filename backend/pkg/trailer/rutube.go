@@ -0,0 +1,44 @@
+package trailer
+
+import "net/url"
+
+// Rutube searches RuTube public API
+type Rutube struct{}
+
+func (r *Rutube) Name() string {
+	return SourceRutube
+}
+
+// Search returns videos found by query
+func (r *Rutube) Search(query string, limit int) ([]Candidate, error) {
+	var response struct {
+		Results []struct {
+			ID           string `json:"id"`
+			Title        string `json:"title"`
+			VideoURL     string `json:"video_url"`
+			EmbedURL     string `json:"embed_url"`
+			ThumbnailURL string `json:"thumbnail_url"`
+		} `json:"results"`
+	}
+	if err := getJSON(SourceRutube, "https://rutube.ru/api/search/video/?format=json&query="+url.QueryEscape(query), &response); err != nil {
+		return nil, err
+	}
+	if len(response.Results) == 0 {
+		return nil, ErrNotFound
+	}
+	var candidates []Candidate
+	for i, result := range response.Results {
+		if i == limit {
+			break
+		}
+		candidates = append(candidates, Candidate{
+			Source:     SourceRutube,
+			ExternalID: result.ID,
+			Title:      result.Title,
+			URL:        result.VideoURL,
+			EmbedURL:   result.EmbedURL,
+			Preview:    result.ThumbnailURL,
+		})
+	}
+	return candidates, nil
+}
@@ -0,0 +1,64 @@
+package trailer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Trailer sources
+const (
+	SourceYoutube = "youtube"
+	SourceVK      = "vk"
+	SourceRutube  = "rutube"
+	SourceUpload  = "upload"
+)
+
+type (
+	// Candidate is a video found by source
+	Candidate struct {
+		Source     string `json:"source"`
+		ExternalID string `json:"external_id"`
+		Title      string `json:"title"`
+		URL        string `json:"url"`       // page of video
+		EmbedURL   string `json:"embed_url"` // player for iframe
+		Preview    string `json:"preview"`
+	}
+	// Source searches trailers on video hosting
+	Source interface {
+		Name() string
+		Search(query string, limit int) ([]Candidate, error)
+	}
+)
+
+// ErrNotFound is returned when source found nothing
+var ErrNotFound = errors.New("no trailer found")
+
+// Query makes search query for movie name
+func Query(movieName string) string {
+	return "трейлер " + movieName
+}
+
+// client doesn't log urls, they contain api keys and access tokens
+var client = &http.Client{Timeout: 15 * time.Second}
+
+// getJSON decodes response of url, errors don't include url
+func getJSON(source, rawURL string, target interface{}) error {
+	res, err := client.Get(rawURL)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("%s: %w", source, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("%s: status %d", source, res.StatusCode)
+	}
+	// api errors come with 4xx status and are described in body
+	return json.NewDecoder(res.Body).Decode(target)
+}
@@ -0,0 +1,65 @@
+package trailer
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// VK searches VK Video, video.search requires user access token
+type VK struct {
+	Token string
+}
+
+func (v *VK) Name() string {
+	return SourceVK
+}
+
+// Search returns videos found by query
+func (v *VK) Search(query string, limit int) ([]Candidate, error) {
+	var response struct {
+		Response struct {
+			Items []struct {
+				ID      int64  `json:"id"`
+				OwnerID int64  `json:"owner_id"`
+				Title   string `json:"title"`
+				Player  string `json:"player"`
+				Image   []struct {
+					URL   string `json:"url"`
+					Width int64  `json:"width"`
+				} `json:"image"`
+			} `json:"items"`
+		} `json:"response"`
+		Error *struct {
+			Message string `json:"error_msg"`
+		} `json:"error"`
+	}
+	err := getJSON(SourceVK, "https://api.vk.com/method/video.search?v=5.199&count="+strconv.Itoa(limit)+"&q="+url.QueryEscape(query)+"&access_token="+v.Token, &response)
+	if err != nil {
+		return nil, err
+	}
+	if response.Error != nil {
+		return nil, errors.New("vk: " + response.Error.Message)
+	}
+	if len(response.Response.Items) == 0 {
+		return nil, ErrNotFound
+	}
+	candidates := make([]Candidate, 0, len(response.Response.Items))
+	for _, item := range response.Response.Items {
+		id := fmt.Sprintf("%d_%d", item.OwnerID, item.ID)
+		candidate := Candidate{
+			Source:     SourceVK,
+			ExternalID: id,
+			Title:      item.Title,
+			URL:        "https://vk.com/video" + id,
+			EmbedURL:   item.Player,
+		}
+		// the last image is the biggest one
+		if len(item.Image) > 0 {
+			candidate.Preview = item.Image[len(item.Image)-1].URL
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, nil
+}
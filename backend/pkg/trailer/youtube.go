@@ -0,0 +1,65 @@
+package trailer
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+)
+
+// Youtube searches with YouTube Data API
+type Youtube struct {
+	APIKey string
+}
+
+func (y *Youtube) Name() string {
+	return SourceYoutube
+}
+
+// Search returns videos found by query
+func (y *Youtube) Search(query string, limit int) ([]Candidate, error) {
+	var response struct {
+		Items []struct {
+			ID struct {
+				VideoID string `json:"videoId"`
+			} `json:"id"`
+			Snippet struct {
+				Title      string `json:"title"`
+				Thumbnails struct {
+					High struct {
+						URL string `json:"url"`
+					} `json:"high"`
+				} `json:"thumbnails"`
+			} `json:"snippet"`
+		} `json:"items"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	err := getJSON(SourceYoutube, "https://www.googleapis.com/youtube/v3/search?part=snippet&type=video&maxResults="+strconv.Itoa(limit)+"&q="+url.QueryEscape(query)+"&key="+y.APIKey, &response)
+	if err != nil {
+		return nil, err
+	}
+	if response.Error != nil {
+		return nil, errors.New("youtube: " + response.Error.Message)
+	}
+	if len(response.Items) == 0 {
+		return nil, ErrNotFound
+	}
+	candidates := make([]Candidate, 0, len(response.Items))
+	for _, item := range response.Items {
+		candidates = append(candidates, YoutubeCandidate(item.ID.VideoID, item.Snippet.Title, item.Snippet.Thumbnails.High.URL))
+	}
+	return candidates, nil
+}
+
+// YoutubeCandidate makes candidate from video id
+func YoutubeCandidate(id, title, preview string) Candidate {
+	return Candidate{
+		Source:     SourceYoutube,
+		ExternalID: id,
+		Title:      title,
+		URL:        "https://www.youtube.com/watch?v=" + id,
+		EmbedURL:   "https://www.youtube.com/embed/" + id,
+		Preview:    preview,
+	}
+}
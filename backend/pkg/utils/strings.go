@@ -39,3 +39,36 @@ func SanitizeInput(inputs []string, delimiter string) string {
 	}
 	return result
 }
+
+var translit = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh", 'з': "z", 'и': "i",
+	'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t",
+	'у': "u", 'ф': "f", 'х': "h", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "sch", 'ъ': "", 'ы': "y", 'ь': "",
+	'э': "e", 'ю': "yu", 'я': "ya",
+}
+
+// Slug makes latin url part from russian or english text, e.g. "Для детей" -> "dlya-detey"
+func Slug(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		case translit[r] != "":
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteString(translit[r])
+			dash = false
+		case r == 'ъ' || r == 'ь':
+		default:
+			dash = true
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,142 @@
+// Package validate checks struct fields by `validate` tags, e.g. `validate:"required,min=1,max=5"`.
+// Rules: required, min and max (value of numbers, length of strings and slices), email, oneof=a b c.
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Rule is one rule of tag
+type Rule struct {
+	Name  string
+	Value string
+}
+
+// Rules parses validate tag
+func Rules(tag string) []Rule {
+	var rules []Rule
+	for _, part := range strings.Split(tag, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(part, "=")
+		rules = append(rules, Rule{Name: name, Value: value})
+	}
+	return rules
+}
+
+// FieldName returns json name of field, empty for skipped fields
+func FieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// Struct returns errors of fields keyed by json names, nil if v is valid
+func Struct(v interface{}) map[string]string {
+	errs := map[string]string{}
+	check(reflect.ValueOf(v), errs)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func check(v reflect.Value, errs map[string]string) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		// fields of embedded structs are promoted like in json, even if struct is unexported
+		if field.Anonymous {
+			check(v.Field(i), errs)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		name := FieldName(field)
+		if name == "" {
+			continue
+		}
+		for _, rule := range Rules(field.Tag.Get("validate")) {
+			if err := checkRule(v.Field(i), rule); err != "" {
+				errs[name] = err
+				break
+			}
+		}
+	}
+}
+
+func checkRule(v reflect.Value, rule Rule) string {
+	switch rule.Name {
+	case "required":
+		if v.IsZero() {
+			return "is required"
+		}
+	case "min", "max":
+		limit, err := strconv.ParseFloat(rule.Value, 64)
+		if err != nil {
+			return ""
+		}
+		value, unit := size(v)
+		if rule.Name == "min" && value < limit {
+			return fmt.Sprintf("must be at least %s%s", rule.Value, unit)
+		}
+		if rule.Name == "max" && value > limit {
+			return fmt.Sprintf("must be at most %s%s", rule.Value, unit)
+		}
+	case "email":
+		if s := v.String(); s != "" {
+			if address, err := mail.ParseAddress(s); err != nil || address.Address != s {
+				return "must be an email"
+			}
+		}
+	case "oneof":
+		if s := fmt.Sprint(v); s != "" {
+			for _, allowed := range strings.Fields(rule.Value) {
+				if s == allowed {
+					return ""
+				}
+			}
+			return "must be one of: " + strings.Join(strings.Fields(rule.Value), ", ")
+		}
+	}
+	return ""
+}
+
+// size returns number value or length with unit for messages
+func size(v reflect.Value) (float64, string) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), " characters"
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len()), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), ""
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), ""
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), ""
+	}
+	return 0, ""
+}
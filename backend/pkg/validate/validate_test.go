@@ -0,0 +1,68 @@
+package validate
+
+import (
+	"reflect"
+	"testing"
+)
+
+type base struct {
+	Phone string `json:"phone" validate:"required"`
+	Lang  string `json:"lang" validate:"oneof=ru en"`
+}
+
+type booking struct {
+	base
+	Email   string   `json:"email" validate:"required,email"`
+	Name    string   `json:"name,omitempty" validate:"min=2,max=5"`
+	Seats   []int64  `json:"seats" validate:"min=1,max=2"`
+	Count   int64    `json:"count" validate:"max=5"`
+	Bank    string   `json:"bank" validate:"oneof=sber tinkoff"`
+	Comment string   `validate:"max=3"`
+	Secret  string   `json:"-" validate:"required"`
+	Tags    []string `json:"tags"`
+}
+
+func TestStruct(t *testing.T) {
+	valid := booking{base: base{Phone: "79990000000"}, Email: "a@b.ru", Name: "Анна", Seats: []int64{1}, Count: 5, Bank: "sber"}
+	tests := []struct {
+		name   string
+		modify func(*booking)
+		want   map[string]string
+	}{
+		{"valid", func(*booking) {}, nil},
+		{"embedded", func(b *booking) { b.Phone = "" }, map[string]string{"phone": "is required"}},
+		{"embedded oneof", func(b *booking) { b.Lang = "de" }, map[string]string{"lang": "must be one of: ru, en"}},
+		{"required", func(b *booking) { b.Email = "" }, map[string]string{"email": "is required"}},
+		{"email", func(b *booking) { b.Email = "Анна <a@b.ru>" }, map[string]string{"email": "must be an email"}},
+		{"runes", func(b *booking) { b.Name = "Анастасия" }, map[string]string{"name": "must be at most 5 characters"}},
+		{"min length", func(b *booking) { b.Name = "А" }, map[string]string{"name": "must be at least 2 characters"}},
+		{"items", func(b *booking) { b.Seats = nil }, map[string]string{"seats": "must be at least 1 items"}},
+		{"number", func(b *booking) { b.Count = 6 }, map[string]string{"count": "must be at most 5"}},
+		{"oneof", func(b *booking) { b.Bank = "other" }, map[string]string{"bank": "must be one of: sber, tinkoff"}},
+		{"field name", func(b *booking) { b.Comment = "long" }, map[string]string{"Comment": "must be at most 3 characters"}},
+	}
+	for _, tt := range tests {
+		b := valid
+		tt.modify(&b)
+		if got := Struct(&b); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if got := Struct((*booking)(nil)); got != nil {
+		t.Errorf("nil pointer: %v", got)
+	}
+}
+
+func TestRules(t *testing.T) {
+	tests := map[string][]Rule{
+		"":                    nil,
+		"required":            {{Name: "required"}},
+		" required , min=1,,": {{Name: "required"}, {Name: "min", Value: "1"}},
+		"oneof=a b c,email":   {{Name: "oneof", Value: "a b c"}, {Name: "email"}},
+	}
+	for tag, want := range tests {
+		if got := Rules(tag); !reflect.DeepEqual(got, want) {
+			t.Errorf("Rules(%q) = %v, want %v", tag, got, want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package variant
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// rule removes variant marker from booking name and gives its label
+type rule struct {
+	re    *regexp.Regexp
+	label string
+}
+
+// cyrillicStart anchors Cyrillic markers to start, space or bracket, as \b is ASCII only in Go,
+// the anchor is kept on replace
+const cyrillicStart = `(?P<pre>^|[\s(\[,/])`
+
+// rules in label order, empty label only removes marker
+var rules = []rule{
+	{regexp.MustCompile(`(?i)\bIMAX\b`), "IMAX"},
+	{regexp.MustCompile(`(?i)\b4DX\b`), "4DX"},
+	{regexp.MustCompile(`(?i)\b3D\b`), "3D"},
+	{regexp.MustCompile(`(?i)\b2D\b`), "2D"},
+	{regexp.MustCompile(`(?i)\bDolby(\s+Atmos)?\b`), "Dolby Atmos"},
+	{regexp.MustCompile(`(?i)` + cyrillicStart + `(?:(с\s+)?(русскими\s+)?субтитр(ами|ы)?\.?|субт\.)`), "субтитры"},
+	{regexp.MustCompile(`(?i)` + cyrillicStart + `(?:(на\s+)?языке\s+оригинала|в\s+оригинале|оригинальн(ая|ой)\s+верси(я|и)|ориг\.)`), "оригинал"},
+	{regexp.MustCompile(`(?i)` + cyrillicStart + `предсеанс\S*(\s+обслуживани\S*)?`), "предсеанс"},
+	{regexp.MustCompile(`\(\s*\d{1,2}\+\s*\)|\s\d{1,2}\+$`), ""},
+}
+
+var (
+	emptyBrackets = regexp.MustCompile(`\(\s*[,;/]*\s*\)|\[\s*\]`)
+	spaces        = regexp.MustCompile(`\s+`)
+)
+
+// Parse splits booking system name into film title and variant label like "3D, субтитры"
+func Parse(name string) (title, label string) {
+	var labels []string
+	for _, r := range rules {
+		if !r.re.MatchString(name) {
+			continue
+		}
+		name = r.re.ReplaceAllString(name, "${pre} ")
+		if r.label != "" {
+			labels = append(labels, r.label)
+		}
+	}
+	name = emptyBrackets.ReplaceAllString(name, " ")
+	name = spaces.ReplaceAllString(name, " ")
+	title = strings.TrimFunc(name, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune("-–—,.:;/|(", r)
+	})
+	return title, strings.Join(labels, ", ")
+}
+
+// Key normalizes title to compare variants, case, ё and punctuation are ignored
+func Key(title string) string {
+	title = strings.ReplaceAll(strings.ToLower(title), "ё", "е")
+	var b strings.Builder
+	space := false
+	for _, r := range title {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteRune(r)
+			space = false
+		} else {
+			space = true
+		}
+	}
+	return b.String()
+}
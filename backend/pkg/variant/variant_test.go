@@ -0,0 +1,52 @@
+package variant
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		label string
+	}{
+		{"Дюна", "Дюна", ""},
+		{"Дюна 3D", "Дюна", "3D"},
+		{"Дюна (IMAX 3D)", "Дюна", "IMAX, 3D"},
+		{"Дюна 2D Dolby Atmos", "Дюна", "2D, Dolby Atmos"},
+		{"Дюна с русскими субтитрами", "Дюна", "субтитры"},
+		{"Дюна субтитры", "Дюна", "субтитры"},
+		{"Дюна с субтитрами", "Дюна", "субтитры"},
+		{"Дюна (с субтитрами)", "Дюна", "субтитры"},
+		{"Дюна субт.", "Дюна", "субтитры"},
+		{"Дюна (3D, субт.)", "Дюна", "3D, субтитры"},
+		{"Дюна на языке оригинала", "Дюна", "оригинал"},
+		{"Дюна ориг.", "Дюна", "оригинал"},
+		{"Дюна - предсеансовое обслуживание", "Дюна", "предсеанс"},
+		{"Дюна (18+)", "Дюна", ""},
+		{"Дюна 18+", "Дюна", ""},
+		// markers inside words are kept
+		{"Подсубт. фильм", "Подсубт. фильм", ""},
+		{"Неоригинал. фильм", "Неоригинал. фильм", ""},
+		{"Бессубтитровый", "Бессубтитровый", ""},
+		{"Мастер-класс и непредсеанс", "Мастер-класс и непредсеанс", ""},
+	}
+	for _, tt := range tests {
+		title, label := Parse(tt.name)
+		if title != tt.title || label != tt.label {
+			t.Errorf("Parse(%q) = %q, %q, want %q, %q", tt.name, title, label, tt.title, tt.label)
+		}
+	}
+}
+
+func TestKey(t *testing.T) {
+	tests := map[string]string{
+		"Ёлки":                 "елки",
+		"Миссия: невыполнима!": "миссия невыполнима",
+		"  Дюна.  Часть 2 ":    "дюна часть 2",
+		"":                     "",
+	}
+	for title, want := range tests {
+		if got := Key(title); got != want {
+			t.Errorf("Key(%q) = %q, want %q", title, got, want)
+		}
+	}
+}
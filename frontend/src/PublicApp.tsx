@@ -10,6 +10,7 @@ const MyTicketsPage = lazy(() => import("@/pages/my-tickets"));
 const ContactsPage = lazy(() => import("@/pages/contacts"));
 const MoviePage = lazy(() => import("@/pages/movie"));
 const PerformancePage = lazy(() => import("@/pages/performance"));
+const ContentPage = lazy(() => import("@/pages/page"));
 
 const FullPageLoader = () => (
   <div className="flex h-screen w-full items-center justify-center">
@@ -28,6 +29,7 @@ function PublicApp() {
         <Route element={<ContactsPage />} path="/contacts" />
         <Route element={<MoviePage />} path="/movie/:id" />
         <Route element={<PerformancePage />} path="/performance/:id" />
+        <Route element={<ContentPage />} path="/page/:slug" />
       </Routes>
     </Suspense>
   );
@@ -4,6 +4,7 @@ import { Link } from "react-router-dom";
 
 import { Movie, Performance } from "@/types";
 import { getImageUrl } from "@/utils/apiClient";
+import { getImageSrcSet } from "@/utils/imageUtils";
 
 interface MovieCardProps {
   movie: Movie;
@@ -54,7 +55,9 @@ const SessionButton = ({
 }) => {
   const performanceId = getPerformanceId(performance);
   const timeLabel = format(toDate(performance.time), "HH:mm");
-  const hall = hallLabel(performance);
+  const hall = [hallLabel(performance), performance.variant]
+    .filter(Boolean)
+    .join(" · ");
   const priceLabel = formatPrice(performance.price, compact);
   const needsHallTicker = hall.length > (compact ? 10 : 16);
   const hallTickerStyle = needsHallTicker
@@ -133,7 +136,9 @@ export const MovieCard = ({ movie, viewMode = "grid" }: MovieCardProps) => {
             alt={movie.name}
             className="h-full w-full object-cover transition-transform duration-700 group-hover:scale-105"
             loading="lazy"
+            sizes="(min-width: 1024px) 25vw, (min-width: 640px) 33vw, 50vw"
             src={posterSrc}
+            srcSet={getImageSrcSet(movie.poster)}
           />
           <div className="absolute inset-0 bg-gradient-to-t from-black/75 via-black/20 to-transparent" />
           <div className="absolute left-3 top-3 rounded-full bg-black/60 px-2.5 py-1 text-[10px] font-bold uppercase tracking-[0.14em] text-white backdrop-blur">
@@ -193,11 +193,11 @@ export default function MoviePage() {
                       {section.label}
                     </Button>
                   ))}
-                  {movie.youtube && (
+                  {movie.trailers?.[0] && (
                     <Button
                       as="a"
                       className="bg-[var(--accent)] text-white"
-                      href={`https://youtu.be/${movie.youtube}`}
+                      href={movie.trailers[0].url}
                       radius="full"
                       rel="noreferrer"
                       size="sm"
@@ -0,0 +1,90 @@
+import { useEffect, useState } from "react";
+import { Link, useParams } from "react-router-dom";
+import { Button } from "@heroui/button";
+import { Spinner } from "@heroui/spinner";
+
+import DefaultLayout from "@/layouts/default";
+import { Page } from "@/types";
+import { apiClient, getImageUrl } from "@/utils/apiClient";
+
+export default function ContentPage() {
+  const { slug } = useParams();
+  const [page, setPage] = useState<Page | null>(null);
+  const [loading, setLoading] = useState(true);
+
+  useEffect(() => {
+    let isMounted = true;
+
+    setLoading(true);
+    apiClient
+      .get<Page>(`/api/pages/${encodeURIComponent(slug || "")}`)
+      .then((data) => {
+        if (!isMounted) return;
+        setPage(data);
+        document.title = data.seo_title || data.title;
+      })
+      .catch(() => {
+        if (!isMounted) return;
+        setPage(null);
+      })
+      .finally(() => {
+        if (!isMounted) return;
+        setLoading(false);
+      });
+
+    return () => {
+      isMounted = false;
+    };
+  }, [slug]);
+
+  if (loading) {
+    return (
+      <DefaultLayout>
+        <div className="flex h-[60vh] items-center justify-center">
+          <Spinner label="Загрузка страницы" size="lg" />
+        </div>
+      </DefaultLayout>
+    );
+  }
+
+  if (!page) {
+    return (
+      <DefaultLayout>
+        <section className="cinema-card flex flex-col items-start gap-4 p-6 md:p-8">
+          <h1 className="type-display text-3xl">Страница не найдена</h1>
+          <Button as={Link} className="cinema-card-soft" to="/" variant="flat">
+            На главную
+          </Button>
+        </section>
+      </DefaultLayout>
+    );
+  }
+
+  return (
+    <DefaultLayout>
+      <div className="flex flex-col gap-6 pb-12">
+        <section className="cinema-card p-6 md:p-8">
+          <h1 className="type-display text-3xl md:text-4xl">{page.title}</h1>
+        </section>
+
+        {page.carousel && page.carousel.length > 0 && (
+          <section className="flex snap-x gap-4 overflow-x-auto pb-2">
+            {page.carousel.map((item) => (
+              <img
+                key={item.url}
+                alt={item.name}
+                className="cinema-card h-64 shrink-0 snap-start object-cover"
+                src={getImageUrl(item.url)}
+              />
+            ))}
+          </section>
+        )}
+
+        <section
+          dangerouslySetInnerHTML={{ __html: page.html }}
+          className="cinema-card type-body max-w-none p-6 md:p-8"
+        />
+      </div>
+    </DefaultLayout>
+  );
+}
@@ -13,10 +13,38 @@ export interface Movie {
   director: string;
   actors: string;
   is_pushkin: boolean;
-  youtube: string;
+  premiere?: string; // ISO String
+  presale_open?: boolean;
+  trailers: Trailer[];
   performances: Performance[];
 }
 
+export interface CarouselItem {
+  name: string;
+  url: string;
+}
+
+export interface Page {
+  id: number;
+  slug: string;
+  title: string;
+  html: string; // rendered body
+  carousel: CarouselItem[] | null;
+  seo_title: string;
+  seo_description: string;
+}
+
+export interface Trailer {
+  id: number;
+  source: "youtube" | "vk" | "rutube" | "upload";
+  external_id: string;
+  title: string;
+  url: string;
+  embed_url: string;
+  preview: string;
+  order: number;
+}
+
 export interface Performance {
   id: number;
   performance_id?: number; // Sometimes API returns this
@@ -26,6 +54,14 @@ export interface Performance {
   time: string; // ISO String
   price: number;
   is3d: boolean;
+  variant?: string; // 3D, субтитры... for grouped film variants
+  format?: string; // 2D, 3D, IMAX, Dolby Atmos, 4DX
+  original_language?: boolean;
+  subtitles?: boolean;
+  audio_description?: boolean;
+  relaxed?: boolean;
+  kids?: boolean;
+  late_show?: boolean;
   places?: Seat[]; // populated when fetching specific performance
 }
 
@@ -1,5 +1,43 @@
 import { getImageUrl } from "./apiClient";
 
+const IMAGES_PREFIX = "/api/images/";
+
+interface ImageVariant {
+  w?: number;
+  h?: number;
+  fit?: "contain" | "cover";
+  blur?: number;
+}
+
+// Вариант изображения из /api/images, старые /uploads отдаются как есть
+export const getImageVariantUrl = (
+  path: string | undefined,
+  variant: ImageVariant,
+): string => {
+  if (!path) return "";
+  if (!path.startsWith(IMAGES_PREFIX)) return getImageUrl(path);
+
+  const params = new URLSearchParams();
+
+  Object.entries(variant).forEach(([key, value]) => {
+    if (value !== undefined) params.set(key, String(value));
+  });
+
+  return getImageUrl(`${path}?${params.toString()}`);
+};
+
+// srcset для адаптивных изображений
+export const getImageSrcSet = (
+  path: string | undefined,
+  widths: number[] = [320, 640, 960, 1280],
+): string | undefined => {
+  if (!path || !path.startsWith(IMAGES_PREFIX)) return undefined;
+
+  return widths
+    .map((w) => `${getImageVariantUrl(path, { w })} ${w}w`)
+    .join(", ");
+};
+
 export const getBackdropUrl = (
   posterPath: string | undefined,
   backdropPath: string | undefined,
@@ -10,6 +48,9 @@ export const getBackdropUrl = (
   }
 
   // 2. Если нет, пытаемся сделать blured версию постера
+  if (posterPath?.startsWith(IMAGES_PREFIX)) {
+    return getImageVariantUrl(posterPath, { w: 960, blur: 35 });
+  }
   if (posterPath) {
     // Пример: /uploads/image.jpg -> /uploads/blured_image.jpg
     const parts = posterPath.split("/");